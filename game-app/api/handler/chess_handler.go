@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/esmailemami/chess/game/internal/app/chess"
 	"github.com/esmailemami/chess/game/internal/app/models"
 	"github.com/esmailemami/chess/game/internal/app/service"
+	chessModels "github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/render"
 	"github.com/esmailemami/chess/shared/errs"
 	"github.com/esmailemami/chess/shared/handler"
 	"github.com/esmailemami/chess/shared/logging"
@@ -14,12 +22,16 @@ import (
 type ChessHandler struct {
 	handler.Handler
 
-	chessService *service.ChessService
+	chessService    *service.ChessService
+	analysisService *service.AnalysisService
+	reviewService   *service.ReviewService
 }
 
-func NewChessHandler(chessService *service.ChessService) *ChessHandler {
+func NewChessHandler(chessService *service.ChessService, analysisService *service.AnalysisService, reviewService *service.ReviewService) *ChessHandler {
 	return &ChessHandler{
-		chessService: chessService,
+		chessService:    chessService,
+		analysisService: analysisService,
+		reviewService:   reviewService,
 	}
 }
 
@@ -88,6 +100,10 @@ func (g *ChessHandler) WatchGame(ctx *gin.Context, id uuid.UUID) (handler.Respon
 // @Failure 422 {object} errs.ValidationError
 // @Router /chess [post]
 func (g *ChessHandler) NewChess(ctx *gin.Context, req models.CreateChessInputModel) (handler.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errs.ValidationErr(err)
+	}
+
 	currentUser := g.GetUser(ctx)
 
 	if currentUser == nil {
@@ -99,6 +115,18 @@ func (g *ChessHandler) NewChess(ctx *gin.Context, req models.CreateChessInputMod
 		return nil, err
 	}
 
+	// a challenged user gets the challenge instead of the board until it is accepted
+	if dbChess.IsChallenge() {
+		challenge, err := g.chessService.GetChallenge(ctx, dbChess.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		chess.Challenge(challenge)
+
+		return handler.OKBool(), nil
+	}
+
 	// send the new chess to the websocket to show users games
 	if err := chess.New(ctx, currentUser.ID, dbChess.ID); err != nil {
 		logging.WarnE("failed to create chess in websocket", err)
@@ -106,3 +134,334 @@ func (g *ChessHandler) NewChess(ctx *gin.Context, req models.CreateChessInputMod
 
 	return handler.OKBool(), nil
 }
+
+// GetChallenges godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} handler.JSONResponse[[]models.ChessChallengeOutputModel]
+// @Failure 400 {object} errs.Error
+// @Router /chess/challenges [get]
+func (g *ChessHandler) GetChallenges(ctx *gin.Context) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	challenges, err := g.chessService.GetChallenges(ctx, currentUser.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(&challenges), nil
+}
+
+// AcceptChallenge godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id   path  string  true  "id"
+// @Success 200 {object} handler.JSONResponse[bool]
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Router /chess/challenges/{id}/accept [post]
+func (g *ChessHandler) AcceptChallenge(ctx *gin.Context, id uuid.UUID) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	if err := g.chessService.AcceptChallenge(ctx, currentUser, id); err != nil {
+		return nil, err
+	}
+
+	chess.AcceptChallenge(currentUser.ID, id)
+
+	return handler.OKBool(), nil
+}
+
+// DeclineChallenge godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id      path  string                             true  "id"
+// @Param input   body  models.DeclineChallengeInputModel  false  "input model, the reason is optional"
+// @Success 200 {object} handler.JSONResponse[bool]
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Failure 422 {object} errs.ValidationError
+// @Router /chess/challenges/{id}/decline [post]
+func (g *ChessHandler) DeclineChallenge(ctx *gin.Context, id uuid.UUID) (handler.Response, error) {
+	var req models.DeclineChallengeInputModel
+
+	// the body is optional, a decline without a reason sends none
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errs.BadRequestErr().WithError(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, errs.ValidationErr(err)
+	}
+
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	if err := g.chessService.DeclineChallenge(ctx, currentUser, id, req.Reason); err != nil {
+		return nil, err
+	}
+
+	chess.DeclineChallenge(currentUser.ID, id, req.Reason)
+
+	return handler.OKBool(), nil
+}
+
+// ExportPGN godoc
+// @Tags chess
+// @Accept json
+// @Produce application/x-chess-pgn
+// @Security Bearer
+// @Param id   path  string  true  "id"
+// @Success 200 {string} string
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Router /chess/{id}/pgn [get]
+func (g *ChessHandler) ExportPGN(ctx *gin.Context, id uuid.UUID) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	game, err := g.chessService.ExportPGN(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "application/x-chess-pgn")
+	ctx.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pgn\"", id))
+
+	if _, err := io.WriteString(ctx.Writer, game.String()); err != nil {
+		return nil, errs.InternalServerErr().Msg("Failed to write pgn to response").WithError(err)
+	}
+
+	return nil, nil
+}
+
+// ImportPGN godoc
+// @Tags chess
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "PGN file to be imported"
+// @Success 200 {object} handler.JSONResponse[uuid.UUID]
+// @Failure 400 {object} errs.Error
+// @Failure 422 {object} errs.ValidationError
+// @Router /chess/import [post]
+func (g *ChessHandler) ImportPGN(ctx *gin.Context) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	files, err := g.GetFiles(ctx, handler.TenMB)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, errs.BadRequestErr().Msg("No file received!")
+	}
+
+	file, err := files[0].Open()
+	if err != nil {
+		return nil, errs.BadRequestErr().Msg("failed to read the file").WithError(err)
+	}
+	defer file.Close()
+
+	dbChess, err := g.chessService.ImportPGN(ctx, currentUser, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(&dbChess.ID), nil
+}
+
+// Analyse godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param input   body  models.AnalyseChessInputModel  true  "input model"
+// @Success 200 {object} handler.JSONResponse[models.ChessAnalysisOutputModel]
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Failure 422 {object} errs.ValidationError
+// @Router /chess/analyse [post]
+func (g *ChessHandler) Analyse(ctx *gin.Context, req models.AnalyseChessInputModel) (handler.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errs.ValidationErr(err)
+	}
+
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	analysis, err := g.analysisService.Analyse(ctx, currentUser, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(analysis), nil
+}
+
+// GetReview godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id   path  string  true  "id"
+// @Success 200 {object} handler.JSONResponse[models.ChessReviewOutputModel]
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Router /chess/{id}/review [get]
+func (g *ChessHandler) GetReview(ctx *gin.Context, id uuid.UUID) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	review, err := g.reviewService.GetReview(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(review), nil
+}
+
+// GetHistory godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param eco      query  string  false  "ECO code of the opening, like B90"
+// @Param opening  query  string  false  "part of the opening name"
+// @Success 200 {object} handler.JSONResponse[[]chessModels.Chess]
+// @Failure 400 {object} errs.Error
+// @Router /chess/history [get]
+func (g *ChessHandler) GetHistory(ctx *gin.Context, req models.ChessHistoryInputModel) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	games, err := g.chessService.GetGamesByUserID(ctx, currentUser.ID, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(&games), nil
+}
+
+// GetOpeningStats godoc
+// @Tags chess
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} handler.JSONResponse[[]models.ChessOpeningStatsOutputModel]
+// @Failure 400 {object} errs.Error
+// @Router /chess/openings [get]
+func (g *ChessHandler) GetOpeningStats(ctx *gin.Context) (handler.Response, error) {
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	stats, err := g.chessService.GetOpeningStats(ctx, currentUser.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.OK(&stats), nil
+}
+
+// GetImage godoc
+// @Tags chess
+// @Accept json
+// @Produce image/svg+xml
+// @Produce image/png
+// @Security Bearer
+// @Param id           path   string  true   "id"
+// @Param ply          query  int     false  "number of moves played, the current position when empty"
+// @Param format       query  string  false  "svg or png"  default(svg)
+// @Param orientation  query  string  false  "white or black, the side at the bottom"  default(white)
+// @Param size         query  int     false  "width and height in pixels"  default(480)
+// @Param arrows       query  string  false  "moves to draw as arrows separated by commas, like e2e4,g1f3"
+// @Success 200 {string} string
+// @Failure 400 {object} errs.Error
+// @Failure 404 {object} errs.Error
+// @Failure 422 {object} errs.ValidationError
+// @Router /chess/{id}/image [get]
+func (g *ChessHandler) GetImage(ctx *gin.Context, id uuid.UUID, req models.ChessImageInputModel) (handler.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, errs.ValidationErr(err)
+	}
+
+	currentUser := g.GetUser(ctx)
+
+	if currentUser == nil {
+		return nil, errs.UnAuthorizedErr()
+	}
+
+	board, lastMove, err := g.chessService.GetPosition(ctx, currentUser, id, req.Ply)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &render.Options{
+		Size:        req.Size,
+		Orientation: chessModels.ChessPlayer(req.Orientation).ChessColor(),
+	}
+
+	if lastMove != nil {
+		opts.LastMove = &render.Move{From: lastMove.From, To: lastMove.To}
+	}
+
+	if req.Arrows != "" {
+		for _, arrow := range strings.Split(req.Arrows, ",") {
+			move, err := chessboard.ParseUCI(strings.TrimSpace(arrow))
+			if err != nil {
+				return nil, errs.BadRequestErr().Msg(fmt.Sprintf("invalid arrow %q", arrow)).WithError(err)
+			}
+
+			opts.Arrows = append(opts.Arrows, render.Move{From: move.From, To: move.To})
+		}
+	}
+
+	if req.Format == "png" {
+		ctx.Writer.Header().Set("Content-Type", "image/png")
+		err = render.PNG(ctx.Writer, board, opts)
+	} else {
+		ctx.Writer.Header().Set("Content-Type", "image/svg+xml")
+		err = render.SVG(ctx.Writer, board, opts)
+	}
+
+	if err != nil {
+		return nil, errs.InternalServerErr().Msg("Failed to write the image to response").WithError(err)
+	}
+
+	return nil, nil
+}
@@ -7,12 +7,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func chessRoutes(r *gin.RouterGroup, chessService *service.ChessService) {
+func chessRoutes(r *gin.RouterGroup, chessService *service.ChessService, analysisService *service.AnalysisService, reviewService *service.ReviewService) {
 	api := r.Group("/chess")
 
-	roomHandler := handler.NewChessHandler(chessService)
+	roomHandler := handler.NewChessHandler(chessService, analysisService, reviewService)
 
 	api.POST("/watch", apiHandler.HandleAPI(roomHandler.WatchGame))
 	api.POST("/join", apiHandler.HandleAPI(roomHandler.JoinGame))
 	api.POST("/", apiHandler.HandleAPI(roomHandler.NewChess))
+	api.GET("/:id/pgn", apiHandler.HandleAPI(roomHandler.ExportPGN))
+	api.POST("/import", apiHandler.HandleAPI(roomHandler.ImportPGN))
+	api.POST("/analyse", apiHandler.HandleAPI(roomHandler.Analyse))
+	api.GET("/:id/review", apiHandler.HandleAPI(roomHandler.GetReview))
+	api.GET("/:id/image", apiHandler.HandleAPI(roomHandler.GetImage))
+	api.GET("/history", apiHandler.HandleAPI(roomHandler.GetHistory))
+	api.GET("/openings", apiHandler.HandleAPI(roomHandler.GetOpeningStats))
+	api.GET("/challenges", apiHandler.HandleAPI(roomHandler.GetChallenges))
+	api.POST("/challenges/:id/accept", apiHandler.HandleAPI(roomHandler.AcceptChallenge))
+	api.POST("/challenges/:id/decline", apiHandler.HandleAPI(roomHandler.DeclineChallenge))
 }
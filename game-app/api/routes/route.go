@@ -14,5 +14,8 @@ func Initialize(r *gin.Engine) {
 
 	chessService := service.NewChessService(redis.GetConnection(), sharedService.NewUserService())
 
-	chessRoutes(route, chessService)
+	analysisService := service.NewAnalysisService(redis.GetConnection())
+	reviewService := service.NewReviewService()
+
+	chessRoutes(route, chessService, analysisService, reviewService)
 }
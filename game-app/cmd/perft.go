@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	perftFEN    string
+	perftDivide bool
+)
+
+// perftCmd counts the leaf nodes of the move tree, used to check the move generator
+var perftCmd = &cobra.Command{
+	Use:   "perft [depth]",
+	Short: "Count the leaf nodes of the legal move tree to the depth",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		depth, err := strconv.Atoi(args[0])
+		if err != nil || depth < 1 {
+			return errors.New("depth must be a positive number")
+		}
+
+		board, err := chessboard.NewFromFEN(perftFEN)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		start := time.Now()
+
+		var nodes int
+
+		if perftDivide {
+			divide := board.Divide(depth)
+
+			moves := make([]string, 0, len(divide))
+			for move := range divide {
+				moves = append(moves, move)
+			}
+			sort.Strings(moves)
+
+			for _, move := range moves {
+				fmt.Fprintf(out, "%s: %d\n", move, divide[move])
+				nodes += divide[move]
+			}
+
+			fmt.Fprintln(out)
+		} else {
+			nodes = board.Perft(depth)
+		}
+
+		elapsed := time.Since(start)
+
+		fmt.Fprintf(out, "nodes: %d\n", nodes)
+		fmt.Fprintf(out, "time: %s\n", elapsed)
+		fmt.Fprintf(out, "nps: %.0f\n", float64(nodes)/elapsed.Seconds())
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(perftCmd)
+
+	perftCmd.Flags().StringVar(&perftFEN, "fen", chessboard.DefaultFEN, "position to start from")
+	perftCmd.Flags().BoolVar(&perftDivide, "divide", false, "print the node count of every move")
+}
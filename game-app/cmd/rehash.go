@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/esmailemami/chess/game/internal/app/service"
+	"github.com/esmailemami/chess/shared/database/redis"
+	sharedService "github.com/esmailemami/chess/shared/service"
+	"github.com/spf13/cobra"
+)
+
+// rehashCmd fills the position hash of the games stored before the hash column existed
+var rehashCmd = &cobra.Command{
+	Use:   "rehash",
+	Short: "Fill the position hash of the stored games",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		chessService := service.NewChessService(redis.GetConnection(), sharedService.NewUserService())
+
+		updated, err := chessService.RehashGames(context.Background())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%d games rehashed\n", updated)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rehashCmd)
+}
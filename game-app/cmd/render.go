@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderSize int
+	renderFlip bool
+)
+
+// renderCmd draws a position to an image file, the format comes from the extension of the file
+var renderCmd = &cobra.Command{
+	Use:   "render [fen] [file]",
+	Short: "Draw the position of the FEN to an SVG or PNG file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		board, err := chessboard.NewFromFEN(args[0])
+		if err != nil {
+			return err
+		}
+
+		opts := &render.Options{Size: renderSize}
+		if renderFlip {
+			opts.Orientation = chessboard.Black
+		}
+
+		file, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		switch filepath.Ext(args[1]) {
+		case ".svg":
+			return render.SVG(file, board, opts)
+		case ".png":
+			return render.PNG(file, board, opts)
+		default:
+			return fmt.Errorf("unknown image format %q, use .svg or .png", filepath.Ext(args[1]))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	renderCmd.Flags().IntVar(&renderSize, "size", render.DefaultSize, "width and height of the image in pixels")
+	renderCmd.Flags().BoolVar(&renderFlip, "flip", false, "draw the board from the side of black")
+}
@@ -0,0 +1,257 @@
+package chess
+
+import (
+	"context"
+	"time"
+
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
+	sharedWebsocket "github.com/esmailemami/chess/shared/websocket"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultWaitingTTL      = 24 * time.Hour
+	defaultSweepInterval   = time.Minute
+	defaultDisconnectGrace = time.Minute
+)
+
+type abandonment struct {
+	chessID uuid.UUID
+	userID  uuid.UUID
+}
+
+// abandonCh gets the players whose disconnect grace period ran out, the Run
+// loop checks again whether they came back in the meantime
+var abandonCh = make(chan *abandonment, 256)
+
+// waitingTTL is how long a game waits for an opponent before it is cancelled
+func waitingTTL() time.Duration {
+	if !viper.IsSet("game.waiting_ttl") {
+		return defaultWaitingTTL
+	}
+
+	return viper.GetDuration("game.waiting_ttl")
+}
+
+func sweepInterval() time.Duration {
+	if interval := viper.GetDuration("game.sweep_interval"); interval > 0 {
+		return interval
+	}
+
+	return defaultSweepInterval
+}
+
+// disconnectGrace is how long a player can be away from an open game before
+// losing it, 0 lets the players leave the game for as long as they like
+func disconnectGrace() time.Duration {
+	if !viper.IsSet("game.disconnect_grace") {
+		return defaultDisconnectGrace
+	}
+
+	return viper.GetDuration("game.disconnect_grace")
+}
+
+// hasMoved reports whether the color has played its first move in the game
+func (b *Board) hasMoved(color chessboard.Color) bool {
+	return b.plies >= 2 || (b.plies == 1 && color != b.chess.Turn)
+}
+
+func (b *Board) isPlayer(userID uuid.UUID) bool {
+	return (b.WhitePlayerUserID != nil && *b.WhitePlayerUserID == userID) ||
+		(b.BlackPlayerUserID != nil && *b.BlackPlayerUserID == userID)
+}
+
+func (b *Board) playerColor(userID uuid.UUID) chessboard.Color {
+	if b.WhitePlayerUserID != nil && *b.WhitePlayerUserID == userID {
+		return chessboard.White
+	}
+	return chessboard.Black
+}
+
+// Abort closes the game without a result, a player can abort a game nobody
+// joined yet or an open game before their first move
+func (b *Board) Abort(req *sharedWebsocket.ClientMessage[websocket.ChessAbortRequest]) error {
+	if b.Status != models.ChessStatusWaiting && b.Status != models.ChessStatusOpen {
+		return ErrGameIsOver
+	}
+
+	if !b.isPlayer(req.UserID) {
+		return ErrInvalidGame
+	}
+
+	// the challenged player declines the challenge instead
+	if b.IsChallenge() {
+		return ErrAbortChallenge
+	}
+
+	if b.hasMoved(b.playerColor(req.UserID)) {
+		return ErrAbortNotAllowed
+	}
+
+	return b.abort(req.Ctx, models.ChessTerminationAborted)
+}
+
+// abort persists the game as closed without a result
+func (b *Board) abort(ctx context.Context, termination models.ChessTermination) error {
+	if err := b.chessService.AbortGame(ctx, b.ChessID, termination); err != nil {
+		return err
+	}
+
+	b.cancel(termination)
+
+	return nil
+}
+
+// cancel closes the game that was stored as closed without a result
+func (b *Board) cancel(termination models.ChessTermination) {
+	if b.clock != nil {
+		b.clock.Stop(time.Now())
+		b.stopFlag()
+	}
+
+	b.Status = models.ChessStatusClose
+	b.takebackRequestedBy = nil
+	b.drawOfferedBy = nil
+	b.stopDisconnectTimers()
+	b.gameOver = &GameOverResponse{
+		Termination: termination,
+	}
+}
+
+// Abandon ends the game the player left, the opponent wins unless the player
+// has not moved yet, then the game is aborted
+func (b *Board) Abandon(ctx context.Context, userID uuid.UUID) error {
+	if b.Status != models.ChessStatusOpen || !b.isPlayer(userID) {
+		return nil
+	}
+
+	color := b.playerColor(userID)
+
+	if !b.hasMoved(color) {
+		return b.abort(ctx, models.ChessTerminationAborted)
+	}
+
+	winner := chessboard.White
+	if color == chessboard.White {
+		winner = chessboard.Black
+	}
+
+	return b.endGame(ctx, models.NewChessResult(&winner), models.ChessTerminationAbandoned)
+}
+
+// startDisconnectTimer gives the player the grace period to come back to the game
+func (b *Board) startDisconnectTimer(userID uuid.UUID, grace time.Duration) {
+	b.stopDisconnectTimer(userID)
+
+	chessID := b.ChessID
+
+	b.disconnectTimers[userID] = time.AfterFunc(grace, func() {
+		abandonCh <- &abandonment{chessID: chessID, userID: userID}
+	})
+}
+
+func (b *Board) stopDisconnectTimer(userID uuid.UUID) {
+	if timer, ok := b.disconnectTimers[userID]; ok {
+		timer.Stop()
+		delete(b.disconnectTimers, userID)
+	}
+}
+
+func (b *Board) stopDisconnectTimers() {
+	for userID := range b.disconnectTimers {
+		b.stopDisconnectTimer(userID)
+	}
+}
+
+func chessAbortRequest(req *sharedWebsocket.ClientMessage[websocket.ChessAbortRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	if err := board.Abort(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	finishGame(board)
+}
+
+// sweepWaitingGames closes the challenges that expired and cancels the games
+// nobody joined within the waiting TTL, 0 keeps the games waiting for good
+func sweepWaitingGames() {
+	var createdBefore *time.Time
+
+	if ttl := waitingTTL(); ttl > 0 {
+		before := time.Now().Add(-ttl)
+		createdBefore = &before
+	}
+
+	cancelled, err := chessService.CancelWaitingGames(context.Background(), createdBefore)
+	if err != nil {
+		logging.ErrorE("failed to cancel the waiting games", err)
+		return
+	}
+
+	for _, chess := range cancelled {
+		board, ok := games[chess.ID]
+		if !ok {
+			continue
+		}
+
+		termination := models.ChessTerminationCancelled
+		if chess.Termination != nil {
+			termination = *chess.Termination
+		}
+
+		board.cancel(termination)
+		finishGame(board)
+	}
+}
+
+// watchDisconnect starts the grace period of a player who has no connection
+// left, the games the player is not part of are left alone
+func watchDisconnect(board *Board, userID uuid.UUID) {
+	grace := disconnectGrace()
+
+	if grace <= 0 || board.Status != models.ChessStatusOpen || !board.isPlayer(userID) {
+		return
+	}
+
+	if len(websocket.ChessWss.GetUserConnections(userID)) > 0 {
+		return
+	}
+
+	board.startDisconnectTimer(userID, grace)
+}
+
+func abandonRequest(left *abandonment) {
+	board, ok := games[left.chessID]
+	if !ok {
+		return
+	}
+
+	delete(board.disconnectTimers, left.userID)
+
+	// the player came back in time
+	if len(websocket.ChessWss.GetUserConnections(left.userID)) > 0 || board.Status != models.ChessStatusOpen {
+		return
+	}
+
+	if err := board.Abandon(context.Background(), left.userID); err != nil {
+		logging.ErrorE("failed to end the abandoned game", err, "chessId", board.ChessID)
+		return
+	}
+
+	if !board.IsGameOver() {
+		return
+	}
+
+	finishGame(board)
+}
@@ -3,11 +3,15 @@ package chess
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/esmailemami/chess/game/internal/app/service"
 	"github.com/esmailemami/chess/game/internal/models"
 	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/clock"
+	"github.com/esmailemami/chess/game/pkg/eco"
 	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
 	sharedWebsocket "github.com/esmailemami/chess/shared/websocket"
 	"github.com/google/uuid"
 )
@@ -21,6 +25,39 @@ type Board struct {
 	Status            models.ChessStatus
 	chessService      *service.ChessService
 
+	// gameOver holds the result once the game is finished
+	gameOver *GameOverResponse
+
+	// takebackRequestedBy is the player waiting for the opponent to answer a takeback request
+	takebackRequestedBy *uuid.UUID
+
+	// drawOfferedBy is the player waiting for the opponent to answer a draw offer
+	drawOfferedBy *uuid.UUID
+
+	// rematchOfferedBy is the player waiting for the opponent to answer a rematch offer after the game,
+	// the offer expires rematchTTL after rematchOfferedAt
+	rematchOfferedBy *uuid.UUID
+	rematchOfferedAt time.Time
+
+	// challengeExpiresAt is when the challenge runs out, nil for a game that is no challenge
+	challengeExpiresAt *time.Time
+
+	// computerLevel is the strength of the computer opponent, 0 when both players are users
+	computerLevel int
+
+	// opening is the classification of the game so far, nil before any known position
+	opening *eco.Opening
+
+	// clock is nil for a game without a time control, flagTimer fires when the side to move runs out of time
+	clock     *clock.Clock
+	flagTimer *time.Timer
+
+	// plies is the number of moves played in the game
+	plies int
+
+	// disconnectTimers fire when a player stayed away from the game for the grace period
+	disconnectTimers map[uuid.UUID]*time.Timer
+
 	mutex sync.Mutex
 
 	connections map[uuid.UUID]*sharedWebsocket.Client
@@ -36,6 +73,7 @@ func newBoard(chessID uuid.UUID, whitePlayerID, blackPlayerID *uuid.UUID, status
 		chessService:      chessService,
 		Status:            status,
 		connections:       make(map[uuid.UUID]*sharedWebsocket.Client),
+		disconnectTimers:  make(map[uuid.UUID]*time.Timer),
 	}
 
 	board.setTurn()
@@ -79,10 +117,22 @@ func (c *Board) swichTurn() {
 }
 
 func (b *Board) setTurn() {
-	if b.WhitePlayerUserID != nil {
+	switch {
+	case b.WhitePlayerUserID != nil && (b.chess.Turn != chessboard.Black || b.BlackPlayerUserID == nil):
 		b.Turn = *b.WhitePlayerUserID
-	} else {
+	case b.BlackPlayerUserID != nil:
 		b.Turn = *b.BlackPlayerUserID
+	default:
+		// nobody plays the game, like an imported game
+		b.Turn = uuid.Nil
+	}
+}
+
+// classify updates the opening after a move, a position out of the table
+// keeps the opening the game has reached so far
+func (b *Board) classify() {
+	if opening := eco.Classify(b.chess); opening != nil {
+		b.opening = opening
 	}
 }
 
@@ -94,11 +144,9 @@ func (b *Board) IsCheckmate() bool {
 	return b.chess.IsCheckmate(b.getTurnColor())
 }
 
+// getTurnColor returns the color to move, the position knows it even when a seat is empty
 func (b *Board) getTurnColor() chessboard.Color {
-	if b.Turn == *b.WhitePlayerUserID {
-		return chessboard.White
-	}
-	return chessboard.Black
+	return b.chess.Turn
 }
 
 func (b *Board) JoinPlayer(ctx context.Context, playerUserID uuid.UUID) error {
@@ -113,6 +161,10 @@ func (b *Board) JoinPlayer(ctx context.Context, playerUserID uuid.UUID) error {
 		b.BlackPlayerUserID = &playerUserID
 	}
 
+	// the game starts once both players are in, like the stored game
+	b.Status = models.ChessStatusOpen
+	b.setTurn()
+
 	return nil
 }
 
@@ -158,52 +210,356 @@ func (b *Board) GetValidMoves(req *sharedWebsocket.ClientMessage[websocket.Chess
 	return validMoves, nil
 }
 
-func (b *Board) PlacePiece(req *sharedWebsocket.ClientMessage[websocket.ChessMovePieceRequest]) (from, to *chessboard.Position, err error) {
-	if err = b.userchecks(req.UserID); err != nil {
-		return nil, nil, err
+func (b *Board) PlacePiece(req *sharedWebsocket.ClientMessage[websocket.ChessMovePieceRequest]) (*chessboard.ChessBoardMove, error) {
+	if err := b.userchecks(req.UserID); err != nil {
+		return nil, err
 	}
 
 	if b.Status == models.ChessStatusWaiting {
-		return nil, nil, ErrGameWaitingStatus
+		return nil, ErrGameWaitingStatus
 	}
 
 	if b.Status != models.ChessStatusOpen {
-		return nil, nil, ErrGameIsOver
+		return nil, ErrGameIsOver
 	}
 
-	from, err = chessboard.GetPosition(req.Data.From)
+	from, err := chessboard.GetPosition(req.Data.From)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	to, err = chessboard.GetPosition(req.Data.To)
+	to, err := chessboard.GetPosition(req.Data.To)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	promotion, err := chessboard.ParsePromotion(req.Data.Promotion)
+
+	if err != nil {
+		return nil, err
 	}
 
 	piece := b.chess.GetPiece(from.Row, from.Col)
-	if err := b.chess.PlacePiece(piece, *to); err != nil {
-		return nil, nil, err
+	if piece == nil {
+		return nil, ErrPieceNotFound
+	}
+
+	if piece.Color != b.getTurnColor() {
+		return nil, ErrInvalidPiece
+	}
+
+	now := time.Now()
+
+	if b.clock != nil && b.clock.Flagged(now) {
+		return nil, ErrTimeIsUp
+	}
+
+	// the state before the move, in case the move can not be saved
+	var (
+		previousClock       clock.Clock
+		previousOpening     = b.opening
+		takebackRequestedBy = b.takebackRequestedBy
+		drawOfferedBy       = b.drawOfferedBy
+	)
+
+	if b.clock != nil {
+		previousClock = *b.clock
+	}
+
+	move, err := b.chess.PlacePiece(piece, *to, promotion)
+	if err != nil {
+		return nil, err
 	}
 
 	b.swichTurn()
+	b.classify()
+	b.plies++
+
+	if b.clock != nil {
+		b.clock.Press(now)
+	}
+
+	// a pending takeback request is about the previous move
+	b.takebackRequestedBy = nil
+
+	// moving instead of answering a draw offer declines it
+	if b.drawOfferedBy != nil && *b.drawOfferedBy != req.UserID {
+		b.drawOfferedBy = nil
+	}
+
+	if err := b.chessService.MoveChessPiece(req.Ctx, b.ChessID, piece, move, b.chess, b.clockModel()); err != nil {
+		// take the move back to stay in sync with the stored game
+		if _, err := b.chess.UnmakeMove(); err != nil {
+			logging.ErrorE("failed to take back the move after a failed save", err, "chessId", b.ChessID)
+		}
+
+		b.setTurn()
+		b.plies--
+		b.opening = previousOpening
+		b.takebackRequestedBy = takebackRequestedBy
+		b.drawOfferedBy = drawOfferedBy
+
+		if b.clock != nil {
+			*b.clock = previousClock
+		}
+
+		return nil, err
+	}
+
+	if result := b.chess.EvaluateResult(b.getTurnColor()); result != nil {
+		if err := b.endGame(req.Ctx, models.NewChessResult(result.Winner), models.ChessTermination(result.Termination)); err != nil {
+			return nil, err
+		}
+	}
+
+	b.scheduleFlag()
+
+	return move, nil
+}
+
+// ClaimDraw ends the game in a draw by threefold repetition or the fifty-move
+// rule, only the player to move can claim it
+func (b *Board) ClaimDraw(req *sharedWebsocket.ClientMessage[websocket.ChessClaimDrawRequest]) error {
+	if err := b.userchecks(req.UserID); err != nil {
+		return err
+	}
+
+	if b.Status != models.ChessStatusOpen {
+		return ErrGameIsOver
+	}
+
+	result, err := b.chess.ClaimDraw()
+	if err != nil {
+		return err
+	}
+
+	return b.endGame(req.Ctx, models.ChessResultDraw, models.ChessTermination(result.Termination))
+}
+
+// RequestTakeback asks the opponent to take back the last move, only the
+// player who played it can ask. It returns the opponent who has to answer.
+func (b *Board) RequestTakeback(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) (uuid.UUID, error) {
+	if b.BlackPlayerUserID == nil || b.WhitePlayerUserID == nil {
+		return uuid.Nil, ErrGameWaitingStatus
+	}
+
+	if b.Status != models.ChessStatusOpen {
+		return uuid.Nil, ErrGameIsOver
+	}
+
+	if !b.isValidUser(req.UserID) {
+		return uuid.Nil, ErrInvalidGame
+	}
+
+	if b.isValidTurn(req.UserID) {
+		return uuid.Nil, ErrTakebackNotAllowed
+	}
+
+	if !b.chess.CanUnmakeMove() {
+		return uuid.Nil, chessboard.ErrNoMoveToUnmake
+	}
+
+	b.takebackRequestedBy = &req.UserID
+
+	return b.Turn, nil
+}
+
+// AcceptTakeback takes back the last move, it returns the move taken back
+func (b *Board) AcceptTakeback(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) (*chessboard.ChessBoardMove, error) {
+	if err := b.checkTakebackAnswer(req.UserID); err != nil {
+		return nil, err
+	}
+
+	move, err := b.chess.UnmakeMove()
+	if err != nil {
+		return nil, err
+	}
+
+	var previousClock clock.Clock
 
-	if err := b.chessService.MoveChessPiece(req.Ctx, b.ChessID, piece, *from, *to); err != nil {
-		return nil, nil, err
+	if b.clock != nil {
+		previousClock = *b.clock
+		b.clock.TakeBack(time.Now())
 	}
 
-	if b.IsCheckmate() {
-		if err := b.chessService.Chectmate(req.Ctx, b.ChessID, b.Turn); err != nil {
-			return nil, nil, err
+	if err := b.chessService.TakeBackChessMove(req.Ctx, b.ChessID, b.chess, b.clockModel()); err != nil {
+		// play the move again to stay in sync with the stored game
+		if _, err := b.chess.MakeMove(move); err != nil {
+			logging.ErrorE("failed to replay the move after a failed takeback", err, "chessId", b.ChessID)
 		}
 
-		// the game is end, so it is close
-		b.Status = models.ChessStatusClose
+		if b.clock != nil {
+			*b.clock = previousClock
+		}
+
+		return nil, err
+	}
+
+	b.scheduleFlag()
+
+	b.takebackRequestedBy = nil
+	b.swichTurn()
+	b.plies--
+
+	// the position the game is back to may be short of the opening it had reached
+	b.opening = eco.Classify(b.chess)
+
+	return move, nil
+}
+
+// DeclineTakeback drops the takeback request, it returns the player who asked for it
+func (b *Board) DeclineTakeback(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) (uuid.UUID, error) {
+	if err := b.checkTakebackAnswer(req.UserID); err != nil {
+		return uuid.Nil, err
+	}
+
+	requestedBy := *b.takebackRequestedBy
+	b.takebackRequestedBy = nil
+
+	return requestedBy, nil
+}
+
+// Resign ends the game with the win of the opponent, a player can resign on either turn
+func (b *Board) Resign(req *sharedWebsocket.ClientMessage[websocket.ChessResignRequest]) error {
+	if b.BlackPlayerUserID == nil || b.WhitePlayerUserID == nil {
+		return ErrGameWaitingStatus
+	}
+
+	if b.Status != models.ChessStatusOpen {
+		return ErrGameIsOver
+	}
+
+	if !b.isValidUser(req.UserID) {
+		return ErrInvalidGame
+	}
+
+	winner := chessboard.White
+	if req.UserID == *b.WhitePlayerUserID {
+		winner = chessboard.Black
+	}
+
+	return b.endGame(req.Ctx, models.NewChessResult(&winner), models.ChessTerminationResignation)
+}
+
+// OfferDraw offers the opponent a draw, the offer stands until the opponent
+// answers or moves. It returns the opponent who has to answer.
+func (b *Board) OfferDraw(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) (uuid.UUID, error) {
+	if b.BlackPlayerUserID == nil || b.WhitePlayerUserID == nil {
+		return uuid.Nil, ErrGameWaitingStatus
+	}
+
+	if b.Status != models.ChessStatusOpen {
+		return uuid.Nil, ErrGameIsOver
+	}
+
+	if !b.isValidUser(req.UserID) {
+		return uuid.Nil, ErrInvalidGame
+	}
+
+	if b.drawOfferedBy != nil {
+		return uuid.Nil, ErrDrawAlreadyOffered
+	}
+
+	b.drawOfferedBy = &req.UserID
+
+	if req.UserID == *b.WhitePlayerUserID {
+		return *b.BlackPlayerUserID, nil
+	}
+	return *b.WhitePlayerUserID, nil
+}
+
+// AcceptDraw ends the game in a draw by agreement
+func (b *Board) AcceptDraw(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) error {
+	if err := b.checkDrawAnswer(req.UserID); err != nil {
+		return err
+	}
+
+	return b.endGame(req.Ctx, models.ChessResultDraw, models.ChessTerminationAgreement)
+}
+
+// DeclineDraw drops the draw offer
+func (b *Board) DeclineDraw(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) error {
+	if err := b.checkDrawAnswer(req.UserID); err != nil {
+		return err
+	}
+
+	b.drawOfferedBy = nil
+
+	return nil
+}
+
+func (b *Board) checkDrawAnswer(userID uuid.UUID) error {
+	if b.Status != models.ChessStatusOpen {
+		return ErrGameIsOver
+	}
+
+	if b.drawOfferedBy == nil {
+		return ErrNoDrawOffer
+	}
+
+	if !b.isValidUser(userID) || userID == *b.drawOfferedBy {
+		return ErrInvalidDrawAnswer
 	}
 
-	return from, to, nil
+	return nil
+}
+
+func (b *Board) checkTakebackAnswer(userID uuid.UUID) error {
+	if b.Status != models.ChessStatusOpen {
+		return ErrGameIsOver
+	}
+
+	if b.takebackRequestedBy == nil {
+		return ErrNoTakebackRequest
+	}
+
+	if !b.isValidUser(userID) || userID == *b.takebackRequestedBy {
+		return ErrInvalidTakebackAnswer
+	}
+
+	return nil
+}
+
+// endGame persists the result and closes the game
+func (b *Board) endGame(ctx context.Context, result models.ChessResult, termination models.ChessTermination) error {
+	var winnerID *uuid.UUID
+
+	switch result {
+	case models.ChessResultWhiteWon:
+		winnerID = b.WhitePlayerUserID
+	case models.ChessResultBlackWon:
+		winnerID = b.BlackPlayerUserID
+	}
+
+	if b.clock != nil {
+		b.clock.Stop(time.Now())
+		b.stopFlag()
+	}
+
+	if err := b.chessService.EndGame(ctx, b.ChessID, result, termination, winnerID, b.clockModel()); err != nil {
+		return err
+	}
+
+	// the game is end, so it is close
+	b.Status = models.ChessStatusClose
+	b.takebackRequestedBy = nil
+	b.drawOfferedBy = nil
+	b.stopDisconnectTimers()
+	b.gameOver = &GameOverResponse{
+		Result:      result,
+		Termination: termination,
+		WinnerID:    winnerID,
+	}
+
+	queueReview(b.ChessID)
+
+	return nil
+}
+
+func (b *Board) IsGameOver() bool {
+	return b.gameOver != nil
 }
 
 func (b *Board) Connect(client *sharedWebsocket.Client) {
@@ -0,0 +1,119 @@
+package chess
+
+import (
+	"context"
+
+	appModels "github.com/esmailemami/chess/game/internal/app/models"
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
+	"github.com/google/uuid"
+)
+
+// challengeCh gets the challenges created over the API, the boards are only touched in the Run loop
+var challengeCh = make(chan *appModels.ChessChallengeOutputModel, 256)
+
+// Challenge shows the new game to the challenger and sends the challenge to the challenged player
+func Challenge(challenge *appModels.ChessChallengeOutputModel) {
+	challengeCh <- challenge
+}
+
+// IsChallenge checks if the game is a challenge waiting for the challenged player to answer
+func (b *Board) IsChallenge() bool {
+	return b.challengeExpiresAt != nil && b.Status == models.ChessStatusWaiting
+}
+
+func challengeRequest(challenge *appModels.ChessChallengeOutputModel) {
+	board, err := getBoard(context.Background(), challenge.ChessID)
+	if err != nil {
+		logging.ErrorE("failed to send the challenge", err, "chessId", challenge.ChessID)
+		return
+	}
+
+	output, err := board.OutPut()
+	if err != nil {
+		logging.ErrorE("failed to send the challenge", err, "chessId", challenge.ChessID)
+		return
+	}
+
+	for _, client := range websocket.ChessWss.GetUserConnections(challenge.Challenger.ID) {
+		board.Connect(client)
+
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.NewBoard, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    output,
+		})
+	}
+
+	for _, client := range websocket.ChessWss.GetUserConnections(challenge.Opponent.ID) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessChallenge, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    challenge,
+		})
+	}
+}
+
+type challengeAnswer struct {
+	userID  uuid.UUID
+	chessID uuid.UUID
+	reason  string
+}
+
+// challengeAcceptCh and challengeDeclineCh get the challenges answered over
+// the API, the boards are only touched in the Run loop
+var (
+	challengeAcceptCh  = make(chan *challengeAnswer, 256)
+	challengeDeclineCh = make(chan *challengeAnswer, 256)
+)
+
+// AcceptChallenge opens the game of the accepted challenge and shows it to both players
+func AcceptChallenge(userID, chessID uuid.UUID) {
+	challengeAcceptCh <- &challengeAnswer{userID: userID, chessID: chessID}
+}
+
+// DeclineChallenge drops the game of the rejected challenge and lets the challenger know
+func DeclineChallenge(userID, chessID uuid.UUID, reason string) {
+	challengeDeclineCh <- &challengeAnswer{userID: userID, chessID: chessID, reason: reason}
+}
+
+func challengeAcceptRequest(answer *challengeAnswer) {
+	ctx := context.Background()
+
+	board, err := getBoard(ctx, answer.chessID)
+	if err != nil {
+		logging.ErrorE("failed to open the accepted challenge", err, "chessId", answer.chessID)
+		return
+	}
+
+	board.Status = models.ChessStatusOpen
+
+	if err := New(ctx, answer.userID, answer.chessID); err != nil {
+		logging.ErrorE("failed to open the accepted challenge", err, "chessId", answer.chessID)
+	}
+}
+
+func challengeDeclineRequest(answer *challengeAnswer) {
+	board, err := getBoard(context.Background(), answer.chessID)
+	if err != nil {
+		logging.ErrorE("failed to drop the declined challenge", err, "chessId", answer.chessID)
+		return
+	}
+
+	board.Status = models.ChessStatusRejected
+
+	challengerID := board.WhitePlayerUserID
+	if challengerID != nil && *challengerID == answer.userID {
+		challengerID = board.BlackPlayerUserID
+	}
+
+	if challengerID != nil {
+		for _, client := range websocket.ChessWss.GetUserConnections(*challengerID) {
+			websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessChallengeDecline, &ChessMessage{
+				ChessID: board.ChessID,
+				Data:    &ChallengeDeclineResponse{UserID: answer.userID, Reason: answer.reason},
+			})
+		}
+	}
+
+	deleteChess(board.ChessID)
+}
@@ -0,0 +1,112 @@
+package chess
+
+import (
+	"context"
+	"time"
+
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/shared/logging"
+	"github.com/google/uuid"
+)
+
+// flagCh gets the games whose flag timer fired, the flag is checked again in
+// the Run loop since the side to move may have moved in the meantime
+var flagCh = make(chan uuid.UUID, 256)
+
+// clockModel returns the clocks to store, nil for a game without a time control
+func (b *Board) clockModel() *models.ChessClock {
+	if b.clock == nil {
+		return nil
+	}
+
+	chessClock := models.NewChessClock(b.clock.Control())
+	chessClock.SetClock(b.clock)
+
+	return chessClock
+}
+
+// scheduleFlag arms the timer to fire when the side to move runs out of time
+func (b *Board) scheduleFlag() {
+	b.stopFlag()
+
+	if b.clock == nil || !b.clock.Running() || b.Status != models.ChessStatusOpen {
+		return
+	}
+
+	chessID := b.ChessID
+
+	b.flagTimer = time.AfterFunc(b.clock.Expiry(time.Now()), func() {
+		flagCh <- chessID
+	})
+}
+
+func (b *Board) stopFlag() {
+	if b.flagTimer != nil {
+		b.flagTimer.Stop()
+		b.flagTimer = nil
+	}
+}
+
+// Flag ends the game when the side to move has run out of time, it reports
+// whether the game ended. The opponent wins unless it has no material to
+// mate with, then the game is drawn.
+func (b *Board) Flag(ctx context.Context) (bool, error) {
+	if b.clock == nil || b.Status != models.ChessStatusOpen || !b.clock.Flagged(time.Now()) {
+		return false, nil
+	}
+
+	winner := chessboard.White
+	if b.clock.Turn() == chessboard.White {
+		winner = chessboard.Black
+	}
+
+	if !b.chess.HasMatingMaterial(winner) {
+		return true, b.endGame(ctx, models.ChessResultDraw, models.ChessTerminationTimeoutVsInsufficientMaterial)
+	}
+
+	return true, b.endGame(ctx, models.NewChessResult(&winner), models.ChessTerminationTimeout)
+}
+
+func flagRequest(chessID uuid.UUID) {
+	board, ok := games[chessID]
+	if !ok {
+		return
+	}
+
+	checkFlag(board)
+}
+
+// checkFlag ends the game of the board when the side to move has run out of
+// time and lets the players know, it reports whether the game ended
+func checkFlag(board *Board) bool {
+	flagged, err := board.Flag(context.Background())
+	if err != nil {
+		logging.ErrorE("failed to end the game on time", err, "chessId", board.ChessID)
+		return false
+	}
+
+	if !flagged {
+		return false
+	}
+
+	finishGame(board)
+
+	return true
+}
+
+// loadClockGames loads the open games played with a clock, so their flag
+// falls on time after a restart even when nobody connects to them
+func loadClockGames() {
+	chessIDs, err := chessService.GetClockChessIDs(context.Background())
+	if err != nil {
+		logging.ErrorE("failed to load the games played with a clock", err)
+		return
+	}
+
+	for _, chessID := range chessIDs {
+		if _, err := getBoard(context.Background(), chessID); err != nil {
+			logging.ErrorE("failed to load the game played with a clock", err, "chessId", chessID)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package chess
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/esmailemami/chess/game/internal/app/service"
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/engine"
+	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
+	sharedWebsocket "github.com/esmailemami/chess/shared/websocket"
+	"github.com/google/uuid"
+)
+
+type computerLevel struct {
+	options engine.Options
+
+	// skill is the Skill Level of the external engine, which has no noise
+	skill int
+}
+
+// computerLevels is the search budget of every computer level, the weak
+// levels search shallow and add noise to the scores so they blunder now and
+// then, the external engine is weakened by its skill instead
+var computerLevels = map[int]computerLevel{
+	1: {engine.Options{Depth: 1, Noise: 400}, 0},
+	2: {engine.Options{Depth: 1, Noise: 200}, 2},
+	3: {engine.Options{Depth: 2, Noise: 120}, 5},
+	4: {engine.Options{Depth: 3, Noise: 60}, 8},
+	5: {engine.Options{Depth: 4, Noise: 30, MoveTime: time.Second}, 11},
+	6: {engine.Options{Depth: 5, Noise: 10, MoveTime: 2 * time.Second}, 14},
+	7: {engine.Options{Depth: 6, MoveTime: 3 * time.Second}, 17},
+	8: {engine.Options{Depth: 8, MoveTime: 5 * time.Second}, 20},
+}
+
+type computerSearch struct {
+	chessID uuid.UUID
+	board   *chessboard.Chessboard
+	level   computerLevel
+}
+
+type computerMove struct {
+	chessID uuid.UUID
+
+	// hash is the position the move was searched in
+	hash uint64
+	move *chessboard.ChessBoardMove
+}
+
+var (
+	computerSearchCh = make(chan *computerSearch, 256)
+	computerMoveCh   = make(chan *computerMove, 256)
+)
+
+// runComputer searches the positions the computer has to move in, the moves
+// go back to the Run loop which plays them like the move of a player
+func runComputer() {
+	for req := range computerSearchCh {
+		result, err := service.SearchComputerMove(context.Background(), req.board, req.level.options, req.level.skill)
+		if err != nil {
+			logging.ErrorE("failed to search the computer move", err, "chessId", req.chessID)
+			continue
+		}
+
+		computerMoveCh <- &computerMove{
+			chessID: req.chessID,
+			hash:    req.board.Hash(),
+			move:    result.Move,
+		}
+	}
+}
+
+// requestComputerMove starts the search of the computer move when it is the computer's turn
+func requestComputerMove(board *Board) {
+	if board.computerLevel == 0 || board.Status != models.ChessStatusOpen || board.Turn != models.ComputerUserID {
+		return
+	}
+
+	req := &computerSearch{
+		chessID: board.ChessID,
+		board:   board.chess.Clone(),
+		level:   computerLevels[board.computerLevel],
+	}
+
+	select {
+	case computerSearchCh <- req:
+	default:
+		logging.Warn("the computer is too busy to move", "chessId", board.ChessID)
+	}
+}
+
+func computerMoveRequest(move *computerMove) {
+	board, ok := games[move.chessID]
+	if !ok {
+		return
+	}
+
+	// the position changed while the computer was thinking, like after a takeback
+	if board.chess.Hash() != move.hash || board.Turn != models.ComputerUserID {
+		return
+	}
+
+	chessMovePieceRequest(&sharedWebsocket.ClientMessage[websocket.ChessMovePieceRequest]{
+		UserID: models.ComputerUserID,
+		Ctx:    context.Background(),
+		Data: websocket.ChessMovePieceRequest{
+			GameID:    board.ChessID,
+			From:      move.move.From.String(),
+			To:        move.move.To.String(),
+			Promotion: string(move.move.Promotion),
+		},
+	})
+}
+
+func startComputer() {
+	for i := 0; i < runtime.NumCPU(); i++ {
+		go runComputer()
+	}
+}
@@ -12,4 +12,19 @@ var (
 	ErrGameWaitingStatus        = errors.New("game is in waiting status")
 	ErrGameIsNotInWaitingStatus = errors.New("game is not in waiting status, you can not play")
 	ErrGameIsOver               = errors.New("game is over")
+	ErrPieceNotFound            = errors.New("there is no piece in the selected position")
+	ErrInvalidPiece             = errors.New("you can only move your own pieces")
+	ErrTakebackNotAllowed       = errors.New("you can only take back your own last move")
+	ErrNoTakebackRequest        = errors.New("there is no takeback request to answer")
+	ErrInvalidTakebackAnswer    = errors.New("only the opponent can answer the takeback request")
+	ErrTimeIsUp                 = errors.New("your time is up")
+	ErrDrawAlreadyOffered       = errors.New("there is already a draw offer to answer")
+	ErrNoDrawOffer              = errors.New("there is no draw offer to answer")
+	ErrInvalidDrawAnswer        = errors.New("only the opponent can answer the draw offer")
+	ErrAbortNotAllowed          = errors.New("a game can only be aborted before your first move")
+	ErrAbortChallenge           = errors.New("a challenge can only be accepted or declined")
+	ErrRematchNotAllowed        = errors.New("a rematch can only be offered after checkmate, resignation or a draw")
+	ErrRematchAlreadyOffered    = errors.New("there is already a rematch offer to answer")
+	ErrNoRematchOffer           = errors.New("there is no rematch offer to answer")
+	ErrInvalidRematchAnswer     = errors.New("only the opponent can answer the rematch offer")
 )
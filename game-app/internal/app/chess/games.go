@@ -5,6 +5,7 @@ import (
 
 	"github.com/esmailemami/chess/game/internal/app/models"
 	"github.com/esmailemami/chess/game/internal/app/service"
+	chessModels "github.com/esmailemami/chess/game/internal/models"
 	"github.com/esmailemami/chess/game/pkg/chessboard"
 	"github.com/esmailemami/chess/shared/database/redis"
 	"github.com/esmailemami/chess/shared/logging"
@@ -27,6 +28,11 @@ func getBoard(ctx context.Context, gameID uuid.UUID) (*Board, error) {
 		return nil, err
 	}
 
+	// a finished game with an empty seat, like an imported game, is never played
+	if game.Status == chessModels.ChessStatusClose && (game.WhitePlayerID == nil || game.BlackPlayerID == nil) {
+		return nil, ErrGameNoPlayers
+	}
+
 	return loadGame(game, chessService)
 }
 
@@ -41,36 +47,72 @@ func loadGame(chess *models.ChessOutputModel, chessService *service.ChessService
 	}
 
 	for i, move := range chess.Moves {
-
-		fromPos, err := chessboard.GetPosition(move.From)
+		chessMove, err := move.ToChessBoardMove()
 
 		if err != nil {
 			logging.ErrorE("failed to parse game move", err)
 			continue
 		}
 
-		toPos, err := chessboard.GetPosition(move.To)
+		moves[i] = chessMove
+	}
 
-		if err != nil {
-			logging.ErrorE("failed to parse game move", err)
-			continue
-		}
+	chessBoard := chessboard.New(pieces, moves)
 
-		moves[i] = &chessboard.ChessBoardMove{
-			From: *fromPos,
-			To:   *toPos,
+	// a game set up from a position replays from there instead of the standard start position
+	if chess.StartFEN != nil {
+		if startBoard, err := chessboard.NewFromFENAndMoves(*chess.StartFEN, moves); err == nil {
+			chessBoard = startBoard
+		} else {
+			logging.ErrorE("failed to replay game from the start FEN", err, "chessId", chess.ID)
 		}
 	}
 
-	chessboard := chessboard.New(pieces, moves)
+	// the stored FEN keeps the castling and en passant state a rebuild from the pieces loses
+	if chess.FEN != "" && chessBoard.FEN() != chess.FEN {
+		if fenBoard, err := chessboard.NewFromFEN(chess.FEN); err == nil {
+			chessBoard = fenBoard
+		} else {
+			logging.ErrorE("failed to load game from FEN", err, "chessId", chess.ID)
+		}
+	}
 
-	board, err := newBoard(chess.ID, chess.WhitePlayerID, chess.BlackPlayerID, chess.Status, chessboard, chessService)
+	board, err := newBoard(chess.ID, chess.WhitePlayerID, chess.BlackPlayerID, chess.Status, chessBoard, chessService)
 	if err != nil {
 		return nil, err
 	}
 
+	if chess.ComputerLevel != nil {
+		board.computerLevel = *chess.ComputerLevel
+	}
+
+	board.classify()
+	board.plies = len(chess.Moves)
+	board.challengeExpiresAt = chess.ChallengeExpiresAt
+
+	// a finished game keeps its result for a rematch
+	if chess.Status == chessModels.ChessStatusClose && chess.Termination != nil {
+		board.gameOver = &GameOverResponse{
+			Termination: *chess.Termination,
+			WinnerID:    chess.Winner,
+			RematchID:   chess.RematchID,
+		}
+
+		if chess.Result != nil {
+			board.gameOver.Result = *chess.Result
+		}
+	}
+
+	if chess.Clock != nil {
+		board.clock = chess.Clock.Clock(chessBoard.Turn, len(chess.Moves))
+		board.scheduleFlag()
+	}
+
 	games[chess.ID] = board
 
+	// the computer may be the one to move, like when it plays white
+	requestComputerMove(board)
+
 	return board, nil
 }
 
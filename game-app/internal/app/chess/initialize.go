@@ -2,19 +2,35 @@ package chess
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/esmailemami/chess/game/internal/app/service"
+	"github.com/esmailemami/chess/game/internal/models"
 	"github.com/esmailemami/chess/game/pkg/websocket"
 	"github.com/esmailemami/chess/shared/database/redis"
+	"github.com/esmailemami/chess/shared/logging"
 	sharedService "github.com/esmailemami/chess/shared/service"
 	sharedWebsocket "github.com/esmailemami/chess/shared/websocket"
 	"github.com/google/uuid"
 )
 
-var chessService *service.ChessService
+var (
+	chessService  *service.ChessService
+	reviewService *service.ReviewService
+)
 
 func Run() {
 	chessService = service.NewChessService(redis.GetConnection(), sharedService.NewUserService())
+	reviewService = service.NewReviewService()
+
+	startComputer()
+	loadClockGames()
+
+	go runReviewer()
+
+	sweeper := time.NewTicker(sweepInterval())
+	defer sweeper.Stop()
 
 	for {
 		select {
@@ -24,6 +40,66 @@ func Run() {
 		case req := <-websocket.ChessMovePieceCh:
 			chessMovePieceRequest(req)
 
+		case req := <-websocket.ChessClaimDrawCh:
+			chessClaimDrawRequest(req)
+
+		case req := <-websocket.ChessRequestTakebackCh:
+			chessRequestTakebackRequest(req)
+
+		case req := <-websocket.ChessTakebackAcceptCh:
+			chessTakebackAcceptRequest(req)
+
+		case req := <-websocket.ChessTakebackDeclineCh:
+			chessTakebackDeclineRequest(req)
+
+		case req := <-websocket.ChessResignCh:
+			chessResignRequest(req)
+
+		case req := <-websocket.ChessDrawOfferCh:
+			chessDrawOfferRequest(req)
+
+		case req := <-websocket.ChessDrawAcceptCh:
+			chessDrawAcceptRequest(req)
+
+		case req := <-websocket.ChessDrawDeclineCh:
+			chessDrawDeclineRequest(req)
+
+		case req := <-websocket.ChessAbortCh:
+			chessAbortRequest(req)
+
+		case req := <-websocket.ChessRematchOfferCh:
+			chessRematchOfferRequest(req)
+
+		case req := <-websocket.ChessRematchAcceptCh:
+			chessRematchAcceptRequest(req)
+
+		case req := <-websocket.ChessRematchDeclineCh:
+			chessRematchDeclineRequest(req)
+
+		case move := <-computerMoveCh:
+			computerMoveRequest(move)
+
+		case chessID := <-flagCh:
+			flagRequest(chessID)
+
+		case left := <-abandonCh:
+			abandonRequest(left)
+
+		case chessID := <-rematchExpireCh:
+			rematchExpireRequest(chessID)
+
+		case challenge := <-challengeCh:
+			challengeRequest(challenge)
+
+		case answer := <-challengeAcceptCh:
+			challengeAcceptRequest(answer)
+
+		case answer := <-challengeDeclineCh:
+			challengeDeclineRequest(answer)
+
+		case <-sweeper.C:
+			sweepWaitingGames()
+
 		case client := <-websocket.ChessRegisterCh:
 			clientOnRegister(client)
 
@@ -62,42 +138,47 @@ func chessMovePieceRequest(req *sharedWebsocket.ClientMessage[websocket.ChessMov
 		return
 	}
 
-	from, to, err := board.PlacePiece(req)
+	// the side to move may have run out of time before the timer fired
+	if checkFlag(board) {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, ErrTimeIsUp.Error())
+		return
+	}
+
+	drawOfferedBy := board.drawOfferedBy
+
+	move, err := board.PlacePiece(req)
 
 	if err != nil {
 		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
 		return
 	}
 
+	resp := NewMovePieceResponse(move).SetOpening(board.opening).SetClock(board.clock)
+
 	for _, client := range board.connections {
 		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessMovePiece, &ChessMessage{
 			ChessID: board.ChessID,
-			Data: &MovePieceResponse{
-				From: from,
-				To:   to,
-			},
+			Data:    resp,
 		})
 	}
 
-	// check for check or checkmate
-	if board.IsCheckmate() {
-		output, err := board.OutPut()
-
-		if err != nil {
-			for _, client := range board.connections {
-				websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessCheckmate, &ChessMessage{
-					ChessID: board.ChessID,
-					Data:    output,
-				})
-			}
+	// the opponent moved instead of answering the draw offer
+	if drawOfferedBy != nil && board.drawOfferedBy == nil && !board.IsGameOver() {
+		for _, client := range board.connections {
+			websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessDrawExpired, &ChessMessage{
+				ChessID: board.ChessID,
+				Data:    &DrawOfferResponse{UserID: *drawOfferedBy},
+			})
 		}
+	}
 
-		// its a checkmate, we have to delete the chess game from the map games
-		deleteChess(board.ChessID)
+	// check for the end of the game or check
+	if board.IsGameOver() {
+		finishGame(board)
 	} else if board.IsInCheck() {
 		output, err := board.OutPut()
 
-		if err != nil {
+		if err == nil {
 			for _, client := range board.connections {
 				websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessInCheck, &ChessMessage{
 					ChessID: board.ChessID,
@@ -106,6 +187,228 @@ func chessMovePieceRequest(req *sharedWebsocket.ClientMessage[websocket.ChessMov
 			}
 		}
 	}
+
+	if !board.IsGameOver() {
+		requestComputerMove(board)
+	}
+}
+
+func chessClaimDrawRequest(req *sharedWebsocket.ClientMessage[websocket.ChessClaimDrawRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	if err := board.ClaimDraw(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	finishGame(board)
+}
+
+func chessRequestTakebackRequest(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	opponentID, err := board.RequestTakeback(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	// the computer always gives the move back
+	if opponentID == models.ComputerUserID {
+		chessTakebackAcceptRequest(&sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]{
+			ClientID: req.ClientID,
+			UserID:   models.ComputerUserID,
+			Ctx:      req.Ctx,
+			Data:     req.Data,
+		})
+		return
+	}
+
+	for _, client := range websocket.ChessWss.GetUserConnections(opponentID) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessRequestTakeback, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &TakebackRequestResponse{UserID: req.UserID},
+		})
+	}
+}
+
+func chessTakebackAcceptRequest(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	move, err := board.AcceptTakeback(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	resp := &TakebackResponse{
+		Move: NewMovePieceResponse(move),
+	}
+
+	output, err := board.OutPut()
+	if err != nil {
+		logging.ErrorE("failed to get the chess output after takeback", err, "chessId", board.ChessID)
+	} else {
+		resp.Chess = output
+	}
+
+	for _, client := range board.connections {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessTakeback, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    resp,
+		})
+	}
+}
+
+func chessTakebackDeclineRequest(req *sharedWebsocket.ClientMessage[websocket.ChessTakebackRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	requestedBy, err := board.DeclineTakeback(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	for _, client := range websocket.ChessWss.GetUserConnections(requestedBy) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessTakebackDecline, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &TakebackRequestResponse{UserID: req.UserID},
+		})
+	}
+}
+
+func chessResignRequest(req *sharedWebsocket.ClientMessage[websocket.ChessResignRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	if err := board.Resign(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	finishGame(board)
+}
+
+func chessDrawOfferRequest(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	opponentID, err := board.OfferDraw(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	for _, client := range board.connections {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessDrawOffer, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &DrawOfferResponse{UserID: req.UserID},
+		})
+	}
+
+	// the computer plays on
+	if opponentID == models.ComputerUserID {
+		chessDrawDeclineRequest(&sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]{
+			ClientID: req.ClientID,
+			UserID:   models.ComputerUserID,
+			Ctx:      req.Ctx,
+			Data:     req.Data,
+		})
+	}
+}
+
+func chessDrawAcceptRequest(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	if err := board.AcceptDraw(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	finishGame(board)
+}
+
+func chessDrawDeclineRequest(req *sharedWebsocket.ClientMessage[websocket.ChessDrawRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	if err := board.DeclineDraw(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	for _, client := range board.connections {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessDrawDecline, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &DrawOfferResponse{UserID: req.UserID},
+		})
+	}
+}
+
+// finishGame lets the connections know the game is over and drops the board,
+// a finished game is loaded again from the database when it is asked for
+func finishGame(board *Board) {
+	sendGameOver(board)
+	deleteChess(board.ChessID)
+}
+
+func sendGameOver(board *Board) {
+	gameOver := *board.gameOver
+
+	output, err := board.OutPut()
+	if err != nil {
+		logging.ErrorE("failed to get the finished chess output", err, "chessId", board.ChessID)
+	} else {
+		gameOver.Chess = output
+	}
+
+	for _, client := range board.connections {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessGameOver, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &gameOver,
+		})
+	}
 }
 
 func clientOnRegister(client *sharedWebsocket.Client) {
@@ -118,11 +421,18 @@ func clientOnRegister(client *sharedWebsocket.Client) {
 
 	for _, chessID := range chessIDs {
 		board, err := getBoard(client.Context, chessID)
+		if errors.Is(err, ErrGameNoPlayers) {
+			continue
+		}
+
 		if err != nil {
 			websocket.ChessWss.SendErrorMessageToClient(client.SessionID, err.Error())
 			continue
 		}
 
+		// the player is back within the grace period
+		board.stopDisconnectTimer(client.UserID)
+
 		output, err := board.OutPut()
 		if err != nil {
 			websocket.ChessWss.SendErrorMessageToClient(client.SessionID, err.Error())
@@ -137,21 +447,15 @@ func clientOnRegister(client *sharedWebsocket.Client) {
 }
 
 func clientOnUnregister(client *sharedWebsocket.Client) {
-	chessIDs, err := chessService.GetChessIDsByUser(client.Context, client.UserID)
-
-	if err != nil {
-		websocket.ChessWss.SendErrorMessageToClient(client.SessionID, err.Error())
-		return
-	}
-
-	chessIDs = append(chessIDs, chessService.GetUserWatchingGames(client.UserID)...)
-
-	for _, chessID := range chessIDs {
-		board, err := getBoard(client.Context, chessID)
-		if err != nil {
-			board.Disconnect(client)
+	// the client is only connected to the boards in memory, a game is never
+	// loaded from the database just to be left
+	for _, board := range games {
+		if _, ok := board.connections[client.SessionID]; !ok && !board.isPlayer(client.UserID) {
+			continue
 		}
 
+		board.Disconnect(client)
+		watchDisconnect(board, client.UserID)
 	}
 
 	// delete watching cache
@@ -1,8 +1,13 @@
 package chess
 
 import (
+	"time"
+
 	"github.com/esmailemami/chess/game/internal/app/models"
+	chessModels "github.com/esmailemami/chess/game/internal/models"
 	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/clock"
+	"github.com/esmailemami/chess/game/pkg/eco"
 	"github.com/esmailemami/chess/shared/websocket"
 	"github.com/google/uuid"
 )
@@ -13,8 +18,114 @@ type ChessMessage struct {
 }
 
 type MovePieceResponse struct {
-	From *chessboard.Position `json:"from"`
-	To   *chessboard.Position `json:"to"`
+	From      chessboard.Position  `json:"from"`
+	To        chessboard.Position  `json:"to"`
+	EnPassant *chessboard.Position `json:"enPassant,omitempty"`
+	Promotion chessboard.PieceType `json:"promotion,omitempty"`
+	Castling  *CastlingResponse    `json:"castling,omitempty"`
+	SAN       string               `json:"san"`
+
+	// ECO and Opening classify the game after the move, empty before any known position
+	ECO     string `json:"eco,omitempty"`
+	Opening string `json:"opening,omitempty"`
+
+	// Clock is the time left after the move, nil for a game without a time control
+	Clock *ClockResponse `json:"clock,omitempty"`
+}
+
+// ClockResponse is the time left of both sides in milliseconds
+type ClockResponse struct {
+	White   int64            `json:"white"`
+	Black   int64            `json:"black"`
+	Turn    chessboard.Color `json:"turn"`
+	Running bool             `json:"running"`
+}
+
+type CastlingResponse struct {
+	Side     chessboard.Castling `json:"side"`
+	RookFrom chessboard.Position `json:"rookFrom"`
+	RookTo   chessboard.Position `json:"rookTo"`
+}
+
+func NewMovePieceResponse(move *chessboard.ChessBoardMove) *MovePieceResponse {
+	resp := &MovePieceResponse{
+		From:      move.From,
+		To:        move.To,
+		EnPassant: move.EnPassant,
+		Promotion: move.Promotion,
+		SAN:       move.SAN,
+	}
+
+	if move.Castling != "" {
+		rookFrom, rookTo := move.CastlingRook()
+
+		resp.Castling = &CastlingResponse{
+			Side:     move.Castling,
+			RookFrom: rookFrom,
+			RookTo:   rookTo,
+		}
+	}
+
+	return resp
+}
+
+// SetOpening adds the classification of the game to the move
+func (m *MovePieceResponse) SetOpening(opening *eco.Opening) *MovePieceResponse {
+	if opening != nil {
+		m.ECO = opening.ECO
+		m.Opening = opening.Name
+	}
+
+	return m
+}
+
+// SetClock adds the time left of both sides to the move
+func (m *MovePieceResponse) SetClock(gameClock *clock.Clock) *MovePieceResponse {
+	if gameClock != nil {
+		now := time.Now()
+
+		m.Clock = &ClockResponse{
+			White:   gameClock.Remaining(chessboard.White, now).Milliseconds(),
+			Black:   gameClock.Remaining(chessboard.Black, now).Milliseconds(),
+			Turn:    gameClock.Turn(),
+			Running: gameClock.Running(),
+		}
+	}
+
+	return m
+}
+
+type TakebackRequestResponse struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+type DrawOfferResponse struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+type RematchOfferResponse struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+type ChallengeDeclineResponse struct {
+	UserID uuid.UUID `json:"userId"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type TakebackResponse struct {
+	Move  *MovePieceResponse   `json:"move"`
+	Chess *ChessOutPutResponse `json:"chess,omitempty"`
+}
+
+type GameOverResponse struct {
+	Result      chessModels.ChessResult      `json:"result,omitempty"`
+	Termination chessModels.ChessTermination `json:"termination"`
+	WinnerID    *uuid.UUID                   `json:"winnerId"`
+
+	// RematchID is the game the players went on to play, the clients switch to its board
+	RematchID *uuid.UUID `json:"rematchId,omitempty"`
+
+	Chess *ChessOutPutResponse `json:"chess,omitempty"`
 }
 
 type ChessOutPutResponse struct {
@@ -0,0 +1,257 @@
+package chess
+
+import (
+	"time"
+
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
+	sharedWebsocket "github.com/esmailemami/chess/shared/websocket"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+const defaultRematchTTL = time.Minute
+
+// rematchExpireCh gets the finished games whose rematch offer may have run out,
+// the Run loop checks the offer again since it may have been answered or renewed
+var rematchExpireCh = make(chan uuid.UUID, 256)
+
+// rematchTTL is how long a rematch offer waits for the opponent
+func rematchTTL() time.Duration {
+	if ttl := viper.GetDuration("game.rematch_ttl"); ttl > 0 {
+		return ttl
+	}
+
+	return defaultRematchTTL
+}
+
+// canRematch reports whether the game ended in a way the players can play again,
+// a game lost on time or abandoned has no rematch
+func (b *Board) canRematch() bool {
+	if b.gameOver == nil || b.gameOver.RematchID != nil {
+		return false
+	}
+
+	return b.gameOver.Result == models.ChessResultDraw ||
+		b.gameOver.Termination == models.ChessTerminationCheckmate ||
+		b.gameOver.Termination == models.ChessTerminationResignation
+}
+
+// OfferRematch offers the opponent a rematch of the finished game, it returns the opponent who has to answer
+func (b *Board) OfferRematch(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) (uuid.UUID, error) {
+	if !b.canRematch() {
+		return uuid.Nil, ErrRematchNotAllowed
+	}
+
+	if b.WhitePlayerUserID == nil || b.BlackPlayerUserID == nil {
+		return uuid.Nil, ErrGameNoPlayers
+	}
+
+	if !b.isPlayer(req.UserID) {
+		return uuid.Nil, ErrInvalidGame
+	}
+
+	if b.rematchOfferedBy != nil {
+		return uuid.Nil, ErrRematchAlreadyOffered
+	}
+
+	b.rematchOfferedBy = &req.UserID
+	b.rematchOfferedAt = time.Now()
+
+	chessID := b.ChessID
+
+	time.AfterFunc(rematchTTL(), func() {
+		rematchExpireCh <- chessID
+	})
+
+	if req.UserID == *b.WhitePlayerUserID {
+		return *b.BlackPlayerUserID, nil
+	}
+	return *b.WhitePlayerUserID, nil
+}
+
+// AcceptRematch starts the rematch with the colors swapped, it returns the new game
+func (b *Board) AcceptRematch(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) (uuid.UUID, error) {
+	if err := b.checkRematchAnswer(req.UserID); err != nil {
+		return uuid.Nil, err
+	}
+
+	rematch, err := b.chessService.Rematch(req.Ctx, b.ChessID, req.UserID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	b.rematchOfferedBy = nil
+	b.gameOver.RematchID = &rematch.ID
+
+	return rematch.ID, nil
+}
+
+// DeclineRematch drops the rematch offer
+func (b *Board) DeclineRematch(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) error {
+	if err := b.checkRematchAnswer(req.UserID); err != nil {
+		return err
+	}
+
+	b.rematchOfferedBy = nil
+
+	return nil
+}
+
+func (b *Board) checkRematchAnswer(userID uuid.UUID) error {
+	if !b.canRematch() {
+		return ErrRematchNotAllowed
+	}
+
+	if b.rematchOfferedBy == nil {
+		return ErrNoRematchOffer
+	}
+
+	if !b.isPlayer(userID) || userID == *b.rematchOfferedBy {
+		return ErrInvalidRematchAnswer
+	}
+
+	return nil
+}
+
+// rematchClients returns the connections of both players and of the watchers
+// still on the board, a finished game may have been loaded again without them
+func rematchClients(board *Board) map[uuid.UUID]*sharedWebsocket.Client {
+	clients := make(map[uuid.UUID]*sharedWebsocket.Client, len(board.connections))
+
+	for sessionID, client := range board.connections {
+		clients[sessionID] = client
+	}
+
+	for _, playerID := range []*uuid.UUID{board.WhitePlayerUserID, board.BlackPlayerUserID} {
+		if playerID == nil {
+			continue
+		}
+
+		for _, client := range websocket.ChessWss.GetUserConnections(*playerID) {
+			clients[client.SessionID] = client
+		}
+	}
+
+	return clients
+}
+
+// releaseFinishedBoard drops the board of a finished game once no rematch offer
+// waits on it, the game is loaded again from the database when it is asked for
+func releaseFinishedBoard(board *Board) {
+	if board.Status == models.ChessStatusClose && board.rematchOfferedBy == nil {
+		deleteChess(board.ChessID)
+	}
+}
+
+func chessRematchOfferRequest(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	defer releaseFinishedBoard(board)
+
+	opponentID, err := board.OfferRematch(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	// the computer always plays again
+	if opponentID == models.ComputerUserID {
+		chessRematchAcceptRequest(&sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]{
+			ClientID: req.ClientID,
+			UserID:   models.ComputerUserID,
+			Ctx:      req.Ctx,
+			Data:     req.Data,
+		})
+		return
+	}
+
+	for _, client := range rematchClients(board) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessRematchOffer, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &RematchOfferResponse{UserID: req.UserID},
+		})
+	}
+}
+
+func chessRematchAcceptRequest(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	rematchID, err := board.AcceptRematch(req)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	// attach the connections of both players to the new board like a new game
+	if err := New(req.Ctx, req.UserID, rematchID); err != nil {
+		logging.ErrorE("failed to create the rematch in websocket", err, "chessId", rematchID)
+	}
+
+	gameOver := *board.gameOver
+
+	for _, client := range rematchClients(board) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessRematch, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &gameOver,
+		})
+	}
+
+	// the players moved on to the rematch
+	deleteChess(board.ChessID)
+}
+
+func chessRematchDeclineRequest(req *sharedWebsocket.ClientMessage[websocket.ChessRematchRequest]) {
+	board, err := getBoard(req.Ctx, req.Data.GameID)
+
+	if err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	defer releaseFinishedBoard(board)
+
+	if err := board.DeclineRematch(req); err != nil {
+		websocket.ChessWss.SendErrorMessageToClient(req.ClientID, err.Error())
+		return
+	}
+
+	for _, client := range rematchClients(board) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessRematchDecline, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &RematchOfferResponse{UserID: req.UserID},
+		})
+	}
+}
+
+func rematchExpireRequest(chessID uuid.UUID) {
+	board, ok := games[chessID]
+	if !ok || board.rematchOfferedBy == nil || time.Since(board.rematchOfferedAt) < rematchTTL() {
+		return
+	}
+
+	offeredBy := *board.rematchOfferedBy
+	board.rematchOfferedBy = nil
+
+	for _, client := range rematchClients(board) {
+		websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessRematchExpired, &ChessMessage{
+			ChessID: board.ChessID,
+			Data:    &RematchOfferResponse{UserID: offeredBy},
+		})
+	}
+
+	releaseFinishedBoard(board)
+}
@@ -0,0 +1,59 @@
+package chess
+
+import (
+	"context"
+
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/websocket"
+	"github.com/esmailemami/chess/shared/logging"
+	"github.com/google/uuid"
+)
+
+// reviewCh queues the finished games to review, one at a time so the
+// reviews do not take the engine away from the games in progress
+var reviewCh = make(chan uuid.UUID, 256)
+
+func runReviewer() {
+	for chessID := range reviewCh {
+		reviewGame(chessID)
+	}
+}
+
+// queueReview starts the review of the finished game in the background
+func queueReview(chessID uuid.UUID) {
+	select {
+	case reviewCh <- chessID:
+	default:
+		logging.Warn("the review queue is full, the game is not reviewed", "chessId", chessID)
+	}
+}
+
+// reviewGame reviews the game and tells its players the review is ready
+func reviewGame(chessID uuid.UUID) {
+	ctx := context.Background()
+
+	review, err := reviewService.ReviewGame(ctx, chessID)
+	if err != nil {
+		logging.ErrorE("failed to review the game", err, "chessId", chessID)
+		return
+	}
+
+	chess, err := chessService.Get(ctx, chessID)
+	if err != nil {
+		logging.ErrorE("failed to get the reviewed game", err, "chessId", chessID)
+		return
+	}
+
+	for _, playerID := range []*uuid.UUID{chess.WhitePlayerID, chess.BlackPlayerID} {
+		if playerID == nil || *playerID == models.ComputerUserID {
+			continue
+		}
+
+		for _, client := range websocket.ChessWss.GetUserConnections(*playerID) {
+			websocket.ChessWss.SendMessageToClient(client.SessionID, websocket.ChessReviewReady, &ChessMessage{
+				ChessID: chessID,
+				Data:    review,
+			})
+		}
+	}
+}
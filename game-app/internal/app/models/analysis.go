@@ -0,0 +1,68 @@
+package models
+
+import (
+	baseconsts "github.com/esmailemami/chess/shared/consts"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultAnalysisLines = 3
+	MaxAnalysisLines     = 5
+)
+
+type AnalyseChessInputModel struct {
+	// FEN is the position to analyse, it can not be used with GameID
+	FEN    string     `json:"fen,omitempty"`
+	GameID *uuid.UUID `json:"gameId,omitempty"`
+
+	// Ply is the number of moves of the game played before the position, the last position when nil
+	Ply *int `json:"ply,omitempty"`
+
+	// Lines is the number of candidate moves, DefaultAnalysisLines when 0
+	Lines int `json:"lines,omitempty"`
+}
+
+func (model AnalyseChessInputModel) Validate() error {
+	return validation.ValidateStruct(
+		&model,
+		validation.Field(
+			&model.FEN,
+			validation.When(model.GameID == nil, validation.Required.Error(baseconsts.Required)),
+			validation.When(model.GameID != nil, validation.Empty.Error(baseconsts.InvalidValue)),
+		),
+		validation.Field(
+			&model.Ply,
+			validation.When(model.GameID == nil, validation.Nil.Error(baseconsts.InvalidValue)),
+			validation.Min(0).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Lines,
+			validation.Min(0).Error(baseconsts.InvalidValue),
+			validation.Max(MaxAnalysisLines).Error(baseconsts.InvalidValue),
+		),
+	)
+}
+
+type ChessAnalysisOutputModel struct {
+	FEN string `json:"fen"`
+
+	// Score is the evaluation in centipawns from the point of view of white
+	Score int `json:"score"`
+
+	// Mate is the number of moves to mate, negative when black mates
+	Mate *int `json:"mate"`
+
+	Depth    int                            `json:"depth"`
+	BestMove string                         `json:"bestMove"`
+	BestLine []string                       `json:"bestLine"`
+	Lines    []ChessAnalysisLineOutputModel `json:"lines"`
+}
+
+type ChessAnalysisLineOutputModel struct {
+	Move  string   `json:"move"`
+	UCI   string   `json:"uci"`
+	Score int      `json:"score"`
+	Mate  *int     `json:"mate"`
+	PV    []string `json:"pv"`
+}
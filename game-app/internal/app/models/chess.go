@@ -1,23 +1,47 @@
 package models
 
 import (
+	"errors"
+	"time"
+
 	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/clock"
+	baseconsts "github.com/esmailemami/chess/shared/consts"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/google/uuid"
 )
 
+const (
+	MinChessImageSize = 64
+	MaxChessImageSize = 2048
+)
+
 type ChessOutputModel struct {
-	ID            uuid.UUID               `json:"id"`
-	WhitePlayerID *uuid.UUID              `json:"whitePlayerId"`
-	WhitePlayer   *ChessPlayerOutputModel `json:"whitePlayer"`
-	BlackPlayerID *uuid.UUID              `json:"blackPlayerId"`
-	BlackPlayer   *ChessPlayerOutputModel `json:"blackPlayer"`
-	Turn          models.ChessPlayer      `json:"turn"`
-	Moves         models.ChessMoves       `json:"moves"`
-	Pieces        models.ChessPieces      `json:"pieces"`
-	Status        models.ChessStatus      `json:"status"`
-	IsInCheck     bool                    `json:"isCheck"`
-	IsCheckmate   bool                    `json:"isCheckmate"`
-	Winner        *uuid.UUID              `json:"winner"`
+	ID            uuid.UUID                `json:"id"`
+	WhitePlayerID *uuid.UUID               `json:"whitePlayerId"`
+	WhitePlayer   *ChessPlayerOutputModel  `json:"whitePlayer"`
+	BlackPlayerID *uuid.UUID               `json:"blackPlayerId"`
+	BlackPlayer   *ChessPlayerOutputModel  `json:"blackPlayer"`
+	Turn          models.ChessPlayer       `json:"turn"`
+	Moves         models.ChessMoves        `json:"moves"`
+	Pieces        models.ChessPieces       `json:"pieces"`
+	FEN           string                   `json:"fen"`
+	Status        models.ChessStatus       `json:"status"`
+	IsInCheck     bool                     `json:"isCheck"`
+	IsCheckmate   bool                     `json:"isCheckmate"`
+	Winner        *uuid.UUID               `json:"winner"`
+	Result        *models.ChessResult      `json:"result"`
+	Termination   *models.ChessTermination `json:"termination"`
+	ComputerLevel *int                     `json:"computerLevel"`
+	StartFEN      *string                  `json:"startFen"`
+	Clock         *models.ChessClock       `json:"clock"`
+	ECO           *string                  `json:"eco"`
+	Opening       *string                  `json:"opening"`
+
+	ChallengeExpiresAt *time.Time `json:"challengeExpiresAt"`
+	DeclineReason      *string    `json:"declineReason"`
+	RematchID          *uuid.UUID `json:"rematchId"`
 }
 
 type ChessPlayerOutputModel struct {
@@ -30,4 +54,230 @@ type ChessPlayerOutputModel struct {
 type CreateChessInputModel struct {
 	Color       string     `json:"color"`
 	PlayingWith *uuid.UUID `json:"playingWith,omitempty"`
+
+	// ComputerLevel plays against the computer at the level from 1 to 8 instead of a user
+	ComputerLevel *int `json:"computerLevel,omitempty"`
+
+	// FEN starts the game from the position instead of the standard start position
+	FEN string `json:"fen,omitempty"`
+
+	// Handicap starts the game without a piece of the creator, one of pawn, knight and queen
+	Handicap models.ChessHandicap `json:"handicap,omitempty"`
+
+	// TimeControl plays the game on a clock, nil for a game without a time limit
+	TimeControl *ChessTimeControlInputModel `json:"timeControl,omitempty"`
+}
+
+// StartFEN returns the position the game starts from, empty for the standard start position
+func (model CreateChessInputModel) StartFEN() (string, error) {
+	if model.Handicap != "" {
+		return model.Handicap.FEN(models.ChessPlayer(model.Color).ChessColor())
+	}
+
+	return model.FEN, nil
+}
+
+func (model CreateChessInputModel) Validate() error {
+	return validation.ValidateStruct(
+		&model,
+		validation.Field(
+			&model.ComputerLevel,
+			validation.Min(models.MinComputerLevel).Error(baseconsts.InvalidValue),
+			validation.Max(models.MaxComputerLevel).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.PlayingWith,
+			validation.When(model.ComputerLevel != nil, validation.Nil.Error(baseconsts.InvalidValue)),
+		),
+		validation.Field(
+			&model.FEN,
+			validation.When(model.Handicap != "", validation.Empty.Error(baseconsts.InvalidValue)),
+			validation.By(validateStartFEN),
+		),
+		validation.Field(
+			&model.Handicap,
+			validation.In(models.ChessHandicapPawn, models.ChessHandicapKnight, models.ChessHandicapQueen).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(&model.TimeControl),
+	)
+}
+
+const (
+	MaxChessInitialTime = 3 * 60 * 60
+	MaxChessIncrement   = 3 * 60
+)
+
+// chessTimeControlPresets are the initial time and the increment of the presets in seconds
+var chessTimeControlPresets = map[string][2]int{
+	"bullet":    {60, 0},
+	"blitz":     {3 * 60, 2},
+	"rapid":     {10 * 60, 5},
+	"classical": {30 * 60, 20},
+}
+
+// ChessTimeControlInputModel is a preset or an initial time with a Fischer
+// increment or a Bronstein delay, the times are in seconds
+type ChessTimeControlInputModel struct {
+	// Preset is one of bullet, blitz, rapid and classical
+	Preset string `json:"preset,omitempty"`
+
+	Initial int `json:"initial,omitempty"`
+
+	// Increment is added to the clock after every move
+	Increment int `json:"increment,omitempty"`
+
+	// Delay gives back the time a move took up to the delay
+	Delay int `json:"delay,omitempty"`
+}
+
+func (model ChessTimeControlInputModel) Validate() error {
+	return validation.ValidateStruct(
+		&model,
+		validation.Field(
+			&model.Preset,
+			validation.In("bullet", "blitz", "rapid", "classical").Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Initial,
+			validation.When(model.Preset == "", validation.Required.Error(baseconsts.Required)).Else(validation.Empty.Error(baseconsts.InvalidValue)),
+			validation.Min(1).Error(baseconsts.InvalidValue),
+			validation.Max(MaxChessInitialTime).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Increment,
+			validation.When(model.Preset != "" || model.Delay != 0, validation.Empty.Error(baseconsts.InvalidValue)),
+			validation.Min(0).Error(baseconsts.InvalidValue),
+			validation.Max(MaxChessIncrement).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Delay,
+			validation.When(model.Preset != "", validation.Empty.Error(baseconsts.InvalidValue)),
+			validation.Min(0).Error(baseconsts.InvalidValue),
+			validation.Max(MaxChessIncrement).Error(baseconsts.InvalidValue),
+		),
+	)
+}
+
+// TimeControl returns the time control of the preset or of the times
+func (model *ChessTimeControlInputModel) TimeControl() clock.TimeControl {
+	if preset, ok := chessTimeControlPresets[model.Preset]; ok {
+		return clock.TimeControl{
+			Initial:   time.Duration(preset[0]) * time.Second,
+			Increment: time.Duration(preset[1]) * time.Second,
+		}
+	}
+
+	return clock.TimeControl{
+		Initial:   time.Duration(model.Initial) * time.Second,
+		Increment: time.Duration(model.Increment) * time.Second,
+		Delay:     time.Duration(model.Delay) * time.Second,
+	}
+}
+
+// validateStartFEN checks that a game can be played from the position
+func validateStartFEN(value any) error {
+	fen, _ := value.(string)
+	if fen == "" {
+		return nil
+	}
+
+	board, err := chessboard.NewFromFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	if err := board.Validate(); err != nil {
+		return err
+	}
+
+	if board.EvaluateResult(board.Turn) != nil {
+		return errors.New("the game is already over in the position")
+	}
+
+	return nil
+}
+
+type ChessHistoryInputModel struct {
+	// ECO keeps the games classified with the code, like B90
+	ECO string `json:"eco"`
+
+	// Opening keeps the games whose opening name contains the text
+	Opening string `json:"opening"`
+}
+
+type ChessOpeningStatsOutputModel struct {
+	ECO     string `json:"eco"`
+	Opening string `json:"opening"`
+	Games   int    `json:"games"`
+	Wins    int    `json:"wins"`
+	Draws   int    `json:"draws"`
+	Losses  int    `json:"losses"`
+}
+
+type ChessImageInputModel struct {
+	// Ply is the number of moves played to reach the position, the current position when nil
+	Ply *int `json:"ply"`
+
+	Format      string `json:"format" default:"svg"`
+	Orientation string `json:"orientation" default:"white"`
+	Size        int    `json:"size" default:"480"`
+
+	// Arrows are moves in the long algebraic notation separated by commas, like e2e4,g1f3
+	Arrows string `json:"arrows"`
+}
+
+func (model ChessImageInputModel) Validate() error {
+	return validation.ValidateStruct(
+		&model,
+		validation.Field(
+			&model.Ply,
+			validation.Min(0).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Format,
+			validation.In("svg", "png").Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Orientation,
+			validation.In(models.ChessPlayerWhite, models.ChessPlayerBlack).Error(baseconsts.InvalidValue),
+		),
+		validation.Field(
+			&model.Size,
+			validation.Min(MinChessImageSize).Error(baseconsts.InvalidValue),
+			validation.Max(MaxChessImageSize).Error(baseconsts.InvalidValue),
+		),
+	)
+}
+
+// ChessChallengeOutputModel is a game a player was challenged to, the color is the challenger's
+type ChessChallengeOutputModel struct {
+	ChessID    uuid.UUID              `json:"chessId"`
+	Challenger ChessPlayerOutputModel `json:"challenger"`
+	Opponent   ChessPlayerOutputModel `json:"opponent"`
+	Color      models.ChessPlayer     `json:"color"`
+	Clock      *models.ChessClock     `json:"clock"`
+	StartFEN   *string                `json:"startFen"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	ExpiresAt  time.Time              `json:"expiresAt"`
+}
+
+type DeclineChallengeInputModel struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+func (model DeclineChallengeInputModel) Validate() error {
+	return validation.ValidateStruct(
+		&model,
+		validation.Field(
+			&model.Reason,
+			validation.Length(0, 255).Error(baseconsts.InvalidValue),
+		),
+	)
+}
+
+type ChessReviewOutputModel struct {
+	ChessID       uuid.UUID               `json:"chessId"`
+	Moves         models.ChessReviewMoves `json:"moves"`
+	WhiteAccuracy float64                 `json:"whiteAccuracy"`
+	BlackAccuracy float64                 `json:"blackAccuracy"`
 }
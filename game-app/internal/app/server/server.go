@@ -6,6 +6,7 @@ import (
 	"github.com/esmailemami/chess/game/api/routes"
 	"github.com/esmailemami/chess/game/docs"
 	"github.com/esmailemami/chess/game/internal/app/chess"
+	"github.com/esmailemami/chess/game/internal/app/service"
 	"github.com/esmailemami/chess/game/pkg/websocket"
 	"github.com/esmailemami/chess/shared/consul"
 	"github.com/esmailemami/chess/shared/middleware"
@@ -29,6 +30,9 @@ func RunServer() {
 	// run the websockets
 	websocket.Run()
 
+	// start the external engine of the config, if any
+	service.StartEngine()
+
 	// run chess game
 	go chess.Run()
 
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appModels "github.com/esmailemami/chess/game/internal/app/models"
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/engine"
+	"github.com/esmailemami/chess/shared/database/psql"
+	"github.com/esmailemami/chess/shared/database/redis"
+	"github.com/esmailemami/chess/shared/errs"
+	"github.com/esmailemami/chess/shared/logging"
+	sharedModels "github.com/esmailemami/chess/shared/models"
+)
+
+var (
+	analysisCacheDuration = 24 * time.Hour
+
+	// analysisOptions is the budget of an analysis, the built-in engine runs out of time long before the depth
+	analysisOptions = engine.Options{
+		Depth:    22,
+		MoveTime: 2 * time.Second,
+	}
+)
+
+type AnalysisService struct {
+	cache *redis.Redis
+}
+
+func NewAnalysisService(cache *redis.Redis) *AnalysisService {
+	return &AnalysisService{
+		cache: cache,
+	}
+}
+
+// Analyse evaluates the position of the FEN or of the game after the ply and
+// finds its best moves, the analysis of a position is cached by its hash
+func (a *AnalysisService) Analyse(ctx context.Context, currentUser *sharedModels.User, req *appModels.AnalyseChessInputModel) (*appModels.ChessAnalysisOutputModel, error) {
+	board, err := a.analysisPosition(ctx, currentUser, req)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := req.Lines
+	if lines == 0 {
+		lines = appModels.DefaultAnalysisLines
+	}
+
+	var (
+		output   appModels.ChessAnalysisOutputModel
+		cacheKey = a.getAnalysisCacheKey(board.Hash(), lines)
+	)
+
+	if err := a.cache.UnmarshalToObject(cacheKey, &output); err == nil {
+		return &output, nil
+	}
+
+	if len(board.GetAllValidMoves(board.Turn)) == 0 {
+		return nil, errs.BadRequestErr().Msg("the game is over in the position, there is no move to analyse")
+	}
+
+	opts := analysisOptions
+	opts.MultiPV = lines
+
+	result, err := SearchPosition(ctx, board, opts)
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	analysis, err := newChessAnalysis(board, result)
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	if err := a.cache.Set(cacheKey, analysis, analysisCacheDuration); err != nil {
+		logging.ErrorE("failed to cache the chess analysis", err)
+	}
+
+	return analysis, nil
+}
+
+// analysisPosition returns the position of the request, the players of a
+// game in progress can not analyse it
+func (a *AnalysisService) analysisPosition(ctx context.Context, currentUser *sharedModels.User, req *appModels.AnalyseChessInputModel) (*chessboard.Chessboard, error) {
+	if req.GameID == nil {
+		board, err := chessboard.NewFromFEN(req.FEN)
+		if err != nil {
+			return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+		}
+
+		// an illegal position is not analysed, nor cached
+		if err := board.Validate(); err != nil {
+			return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+		}
+
+		return board, nil
+	}
+
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", *req.GameID).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if chess.Status == models.ChessStatusOpen && isChessPlayer(&chess, currentUser) {
+		return nil, errs.BadRequestErr().Msg("you can not analyse your game before it is over")
+	}
+
+	ply := len(chess.Moves)
+	if req.Ply != nil {
+		ply = *req.Ply
+	}
+
+	if ply > len(chess.Moves) {
+		return nil, errs.BadRequestErr().Msg(fmt.Sprintf("the game has only %d moves", len(chess.Moves)))
+	}
+
+	moves := make([]*chessboard.ChessBoardMove, ply)
+
+	for i, chessMove := range chess.Moves[:ply] {
+		move, err := chessMove.ToChessBoardMove()
+		if err != nil {
+			return nil, errs.InternalServerErr().WithError(err)
+		}
+
+		moves[i] = move
+	}
+
+	board, err := chessboard.NewFromFENAndMoves(chess.StartingFEN(), moves)
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	return board, nil
+}
+
+func isChessPlayer(chess *models.Chess, user *sharedModels.User) bool {
+	return (chess.WhitePlayerID != nil && *chess.WhitePlayerID == user.ID) ||
+		(chess.BlackPlayerID != nil && *chess.BlackPlayerID == user.ID)
+}
+
+// newChessAnalysis writes the result of the search in standard algebraic
+// notation with the scores from the point of view of white
+func newChessAnalysis(board *chessboard.Chessboard, result *engine.Result) (*appModels.ChessAnalysisOutputModel, error) {
+	output := &appModels.ChessAnalysisOutputModel{
+		FEN:   board.FEN(),
+		Depth: result.Depth,
+		Lines: make([]appModels.ChessAnalysisLineOutputModel, len(result.Lines)),
+	}
+
+	for i, line := range result.Lines {
+		pv, err := sanLine(board, line.PV)
+		if err != nil {
+			return nil, err
+		}
+
+		score, mate := whiteScore(board.Turn, line.Score)
+
+		output.Lines[i] = appModels.ChessAnalysisLineOutputModel{
+			Move:  pv[0],
+			UCI:   line.Move.UCI(),
+			Score: score,
+			Mate:  mate,
+			PV:    pv,
+		}
+	}
+
+	best := output.Lines[0]
+	output.BestMove, output.BestLine, output.Score, output.Mate = best.Move, best.PV, best.Score, best.Mate
+
+	return output, nil
+}
+
+// sanLine plays the moves on a copy of the board and returns their notation
+func sanLine(board *chessboard.Chessboard, moves []*chessboard.ChessBoardMove) ([]string, error) {
+	var (
+		clone = board.Clone()
+		line  = make([]string, len(moves))
+	)
+
+	for i, move := range moves {
+		piece := clone.GetPiece(move.From.Row, move.From.Col)
+		if piece == nil {
+			return nil, fmt.Errorf("there is no piece in %s", move.From)
+		}
+
+		played, err := clone.PlacePiece(piece, move.To, move.Promotion)
+		if err != nil {
+			return nil, err
+		}
+
+		line[i] = played.SAN
+	}
+
+	return line, nil
+}
+
+// whiteScore turns the score of the side to move to the score and the moves to mate of white
+func whiteScore(turn chessboard.Color, score int) (int, *int) {
+	if turn == chessboard.Black {
+		score = -score
+	}
+
+	if mate := engine.MateIn(score); mate != 0 {
+		return score, &mate
+	}
+
+	return score, nil
+}
+
+func (a *AnalysisService) getAnalysisCacheKey(hash uint64, lines int) string {
+	return fmt.Sprintf("chess_analysis_%d_%d", hash, lines)
+}
@@ -2,18 +2,27 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	appModels "github.com/esmailemami/chess/game/internal/app/models"
 	"github.com/esmailemami/chess/game/internal/models"
 	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/eco"
+	"github.com/esmailemami/chess/game/pkg/pgn"
 	"github.com/esmailemami/chess/shared/database/psql"
 	"github.com/esmailemami/chess/shared/database/redis"
 	"github.com/esmailemami/chess/shared/errs"
+	"github.com/esmailemami/chess/shared/logging"
 	sharedModels "github.com/esmailemami/chess/shared/models"
 	"github.com/esmailemami/chess/shared/service"
 	"github.com/esmailemami/chess/shared/util"
 	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -21,6 +30,17 @@ var (
 	chessWatcherCacheDuration = 5 * time.Hour
 )
 
+// defaultChallengeTTL is how long a challenge waits for an answer when the config has no TTL
+const defaultChallengeTTL = 10 * time.Minute
+
+func challengeTTL() time.Duration {
+	if ttl := viper.GetDuration("game.challenge_ttl"); ttl > 0 {
+		return ttl
+	}
+
+	return defaultChallengeTTL
+}
+
 type ChessService struct {
 	service.BaseService[models.Chess]
 
@@ -35,19 +55,50 @@ func NewChessService(cache *redis.Redis, userService *service.UserService) *Ches
 	}
 }
 
-func (*ChessService) GetGamesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Chess, error) {
+// GetGamesByUserID returns the games of the user, the newest first, filtered by the opening of the input
+func (*ChessService) GetGamesByUserID(ctx context.Context, userID uuid.UUID, input *appModels.ChessHistoryInputModel) ([]models.Chess, error) {
 	var Chesss []models.Chess
 
 	db := psql.DBContext(ctx)
 
-	if err := db.Model(&models.Chess{}).Where("white_player_id=? OR black_player_id=?", userID, userID).
-		Find(&Chesss).Error; err != nil {
+	qry := db.Model(&models.Chess{}).Where("white_player_id=? OR black_player_id=?", userID, userID)
+
+	if input.ECO != "" {
+		qry = qry.Where("eco=?", strings.ToUpper(input.ECO))
+	}
+
+	if input.Opening != "" {
+		qry = qry.Where("opening ILIKE ?", "%"+input.Opening+"%")
+	}
+
+	if err := qry.Order("created_at DESC").Find(&Chesss).Error; err != nil {
 		return nil, errs.InternalServerErr().WithError(err)
 	}
 
 	return Chesss, nil
 }
 
+// GetOpeningStats returns how the user scored with every opening of their finished games, the most played first
+func (*ChessService) GetOpeningStats(ctx context.Context, userID uuid.UUID) ([]appModels.ChessOpeningStatsOutputModel, error) {
+	var stats []appModels.ChessOpeningStatsOutputModel
+
+	db := psql.DBContext(ctx)
+
+	if err := db.Model(&models.Chess{}).
+		Select(`eco, opening, COUNT(*) AS games,
+			COUNT(*) FILTER (WHERE winner_id = ?) AS wins,
+			COUNT(*) FILTER (WHERE result = ?) AS draws,
+			COUNT(*) FILTER (WHERE winner_id <> ?) AS losses`, userID, models.ChessResultDraw, userID).
+		Where("(white_player_id=? OR black_player_id=?) AND status=? AND result IS NOT NULL AND eco IS NOT NULL", userID, userID, models.ChessStatusClose).
+		Group("eco, opening").
+		Order("games DESC, eco").
+		Scan(&stats).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	return stats, nil
+}
+
 func (*ChessService) GetChessIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
 	var Chesss []uuid.UUID
 
@@ -62,6 +113,21 @@ func (*ChessService) GetChessIDsByUser(ctx context.Context, userID uuid.UUID) ([
 	return Chesss, nil
 }
 
+// GetClockChessIDs returns the open games played with a clock, their flag has to fall even when nobody moves
+func (*ChessService) GetClockChessIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var chessIDs []uuid.UUID
+
+	db := psql.DBContext(ctx)
+
+	if err := db.Model(&models.Chess{}).Where("status=? AND clock IS NOT NULL", models.ChessStatusOpen).
+		Select("id").
+		Find(&chessIDs).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	return chessIDs, nil
+}
+
 func (g *ChessService) Get(ctx context.Context, id uuid.UUID) (*appModels.ChessOutputModel, error) {
 	var output appModels.ChessOutputModel
 
@@ -89,7 +155,8 @@ func (g *ChessService) JoinGame(ctx context.Context, currentUser *sharedModels.U
 		return errs.NotFoundErr()
 	}
 
-	if chess.Status != models.ChessStatusWaiting {
+	// a challenge is answered by the challenged player, nobody else can take the seat
+	if chess.Status != models.ChessStatusWaiting || chess.ChallengeExpiresAt != nil {
 		return errs.BadRequestErr().Msg("you can not join the Chess")
 	}
 
@@ -108,7 +175,9 @@ func (g *ChessService) JoinGame(ctx context.Context, currentUser *sharedModels.U
 	return nil
 }
 
-func (g *ChessService) MoveChessPiece(ctx context.Context, id uuid.UUID, piece *chessboard.Piece, from, to chessboard.Position) error {
+// MoveChessPiece persists the move, board is the position after the move and
+// clock the clocks after it, nil for a game without a time control
+func (g *ChessService) MoveChessPiece(ctx context.Context, id uuid.UUID, piece *chessboard.Piece, move *chessboard.ChessBoardMove, board *chessboard.Chessboard, clock *models.ChessClock) error {
 	db := psql.DBContext(ctx)
 
 	var chess models.Chess
@@ -117,39 +186,134 @@ func (g *ChessService) MoveChessPiece(ctx context.Context, id uuid.UUID, piece *
 		return errs.NotFoundErr().WithError(err)
 	}
 
-	for i, piece := range chess.Pieces {
-		if !(piece.Row == from.Row && piece.Col == from.Col) && !(piece.Row == to.Row && piece.Col == to.Col) {
-			continue
-		}
+	chess.Pieces = removeChessPiece(chess.Pieces, move.From)
+	chess.Pieces = removeChessPiece(chess.Pieces, move.To)
 
-		chess.Pieces = util.ArrayRemoveIndex[models.ChessPiece](chess.Pieces, i)
+	// the pawn captured en passant is not on the destination square
+	if move.EnPassant != nil {
+		chess.Pieces = removeChessPiece(chess.Pieces, *move.EnPassant)
 	}
 
 	player := models.GetChessPlayerFromColor(piece.Color)
 
+	// the piece has already been moved, so a promoted pawn carries its new type
 	chess.Pieces = append(chess.Pieces, models.ChessPiece{
 		Piece:  string(piece.Type),
-		Row:    to.Row,
-		Col:    to.Col,
+		Row:    move.To.Row,
+		Col:    move.To.Col,
 		Player: player,
 	})
 
-	chess.Moves = append(chess.Moves, models.ChessMove{
-		Player: player,
-		From:   from.String(),
-		To:     to.String(),
-	})
+	// castling moves the rook in the same move as the king
+	if move.Castling != "" {
+		rookFrom, rookTo := move.CastlingRook()
+
+		chess.Pieces = removeChessPiece(chess.Pieces, rookFrom)
+		chess.Pieces = append(chess.Pieces, models.ChessPiece{
+			Piece:  string(chessboard.Rook),
+			Row:    rookTo.Row,
+			Col:    rookTo.Col,
+			Player: player,
+		})
+	}
 
+	chess.Moves = append(chess.Moves, models.NewChessMove(piece.Color, move))
+
+	chess.SetPosition(board)
 	chess.SwitchTurn()
 
+	// a position out of the table keeps the opening the game has reached so far
+	if opening := eco.Classify(board); opening != nil {
+		chess.SetOpening(opening)
+	}
+
+	if clock != nil {
+		chess.Clock = clock
+	}
+
 	if err := db.Save(&chess).Error; err != nil {
 		return errs.InternalServerErr().WithError(err)
 	}
 
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
 	return nil
 }
 
-func (g *ChessService) Chectmate(ctx context.Context, id uuid.UUID, winnerID uuid.UUID) error {
+// TakeBackChessMove removes the last move of the game, board is the position before it
+// and clock the clocks after the takeback, nil for a game without a time control
+func (g *ChessService) TakeBackChessMove(ctx context.Context, id uuid.UUID, board *chessboard.Chessboard, clock *models.ChessClock) error {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", id).Error; err != nil {
+		return errs.NotFoundErr().WithError(err)
+	}
+
+	if len(chess.Moves) == 0 {
+		return errs.BadRequestErr().Msg("there is no move to take back")
+	}
+
+	chess.Moves = chess.Moves[:len(chess.Moves)-1]
+	chess.Pieces = models.NewChessPieces(board.GetPieces())
+	chess.SetPosition(board)
+	chess.SwitchTurn()
+	chess.SetOpening(eco.Classify(board))
+
+	if clock != nil {
+		chess.Clock = clock
+	}
+
+	if err := db.Save(&chess).Error; err != nil {
+		return errs.InternalServerErr().WithError(err)
+	}
+
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
+	return nil
+}
+
+// EndGame closes the game with the result, winnerID is nil when the game is drawn
+// and clock the stopped clocks, nil for a game without a time control
+func (g *ChessService) EndGame(ctx context.Context, id uuid.UUID, result models.ChessResult, termination models.ChessTermination, winnerID *uuid.UUID, clock *models.ChessClock) error {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", id).Error; err != nil {
+		return errs.NotFoundErr().WithError(err)
+	}
+
+	chess.Status = models.ChessStatusClose
+	chess.WinnerID = winnerID
+	chess.Result = &result
+	chess.Termination = &termination
+
+	if clock != nil {
+		chess.Clock = clock
+	}
+
+	if err := db.Save(&chess).Error; err != nil {
+		return errs.InternalServerErr().WithError(err)
+	}
+
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
+	return nil
+}
+
+// AbortGame closes the game without a result, like a game aborted before the players moved
+func (g *ChessService) AbortGame(ctx context.Context, id uuid.UUID, termination models.ChessTermination) error {
 	db := psql.DBContext(ctx)
 
 	var chess models.Chess
@@ -159,15 +323,260 @@ func (g *ChessService) Chectmate(ctx context.Context, id uuid.UUID, winnerID uui
 	}
 
 	chess.Status = models.ChessStatusClose
-	chess.WinnerID = &winnerID
+	chess.Termination = &termination
 
 	if err := db.Save(&chess).Error; err != nil {
 		return errs.InternalServerErr().WithError(err)
 	}
 
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
 	return nil
 }
 
+// CancelWaitingGames closes the challenges that expired and, when createdBefore
+// is set, the games created before it that nobody joined. It returns the
+// closed games with their termination.
+func (g *ChessService) CancelWaitingGames(ctx context.Context, createdBefore *time.Time) ([]models.Chess, error) {
+	var cancelled []models.Chess
+
+	db := psql.DBContext(ctx)
+
+	query := db.Model(&cancelled).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "termination"}}}).
+		Where("status=?", models.ChessStatusWaiting)
+
+	if createdBefore != nil {
+		query = query.Where("((challenge_expires_at IS NULL AND created_at<?) OR challenge_expires_at<?)", *createdBefore, time.Now())
+	} else {
+		query = query.Where("challenge_expires_at<?", time.Now())
+	}
+
+	if err := query.Updates(map[string]any{
+		"status":      models.ChessStatusClose,
+		"termination": gorm.Expr("CASE WHEN challenge_expires_at IS NULL THEN ? ELSE ? END", models.ChessTerminationCancelled, models.ChessTerminationExpired),
+	}).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	for _, chess := range cancelled {
+		if _, err := g.setChessCache(ctx, chess.ID); err != nil {
+			logging.ErrorE("failed to reset chess cache", err)
+		}
+	}
+
+	return cancelled, nil
+}
+
+// Rematch starts a new game of the players of the finished game with the
+// colors swapped, the start position and the time control stay the same
+func (g *ChessService) Rematch(ctx context.Context, id uuid.UUID, createdByID uuid.UUID) (*models.Chess, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.Preload("WhitePlayer").Preload("BlackPlayer").First(&chess, "id = ?", id).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if chess.Status != models.ChessStatusClose || chess.Result == nil {
+		return nil, errs.BadRequestErr().Msg("only a finished game can be rematched")
+	}
+
+	if chess.RematchID != nil {
+		return nil, errs.BadRequestErr().Msg("the game already has a rematch")
+	}
+
+	board, err := chessboard.NewFromFEN(chess.StartingFEN())
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	rematch := models.NewChess(chess.BlackPlayer, chess.WhitePlayer, board)
+	rematch.ComputerLevel = chess.ComputerLevel
+	rematch.StartFEN = chess.StartFEN
+	rematch.CreatedByID = &createdByID
+
+	if chess.Clock != nil {
+		rematch.Clock = models.NewChessClock(chess.Clock.TimeControl())
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rematch).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&chess).UpdateColumn("rematch_id", rematch.ID).Error
+	})
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
+	return rematch, nil
+}
+
+// GetChallenges returns the challenges of the user waiting for an answer, the ones the user sent included
+func (g *ChessService) GetChallenges(ctx context.Context, userID uuid.UUID) ([]appModels.ChessChallengeOutputModel, error) {
+	var challenges []models.Chess
+
+	db := psql.DBContext(ctx)
+
+	if err := db.Preload("WhitePlayer").Preload("BlackPlayer").
+		Where("(white_player_id=? OR black_player_id=?) AND status=? AND challenge_expires_at>?", userID, userID, models.ChessStatusWaiting, time.Now()).
+		Order("created_at DESC").
+		Find(&challenges).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	output := make([]appModels.ChessChallengeOutputModel, 0, len(challenges))
+
+	for i := range challenges {
+		if challenge := newChallengeOutput(&challenges[i]); challenge != nil {
+			output = append(output, *challenge)
+		}
+	}
+
+	return output, nil
+}
+
+// GetChallenge returns the challenge of the game
+func (g *ChessService) GetChallenge(ctx context.Context, id uuid.UUID) (*appModels.ChessChallengeOutputModel, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.Preload("WhitePlayer").Preload("BlackPlayer").First(&chess, "id=?", id).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	challenge := newChallengeOutput(&chess)
+	if challenge == nil {
+		return nil, errs.NotFoundErr()
+	}
+
+	return challenge, nil
+}
+
+// AcceptChallenge opens the game the user was challenged to
+func (g *ChessService) AcceptChallenge(ctx context.Context, currentUser *sharedModels.User, id uuid.UUID) error {
+	db := psql.DBContext(ctx)
+
+	chess, err := getChallenge(ctx, currentUser.ID, id)
+	if err != nil {
+		return err
+	}
+
+	chess.Status = models.ChessStatusOpen
+
+	if err := db.Save(chess).Error; err != nil {
+		return errs.InternalServerErr().WithError(err)
+	}
+
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
+	return nil
+}
+
+// DeclineChallenge rejects the game the user was challenged to, the reason is optional
+func (g *ChessService) DeclineChallenge(ctx context.Context, currentUser *sharedModels.User, id uuid.UUID, reason string) error {
+	db := psql.DBContext(ctx)
+
+	chess, err := getChallenge(ctx, currentUser.ID, id)
+	if err != nil {
+		return err
+	}
+
+	chess.Status = models.ChessStatusRejected
+
+	if reason != "" {
+		chess.DeclineReason = &reason
+	}
+
+	if err := db.Save(chess).Error; err != nil {
+		return errs.InternalServerErr().WithError(err)
+	}
+
+	// reset the cache
+	if _, err := g.setChessCache(ctx, id); err != nil {
+		logging.ErrorE("failed to reset chess cache", err)
+	}
+
+	return nil
+}
+
+// getChallenge returns the challenge the user can answer, the challenger can not answer their own challenge
+func getChallenge(ctx context.Context, userID, id uuid.UUID) (*models.Chess, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", id).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if !chess.IsChallenge() {
+		return nil, errs.BadRequestErr().Msg("the game is not a challenge waiting for an answer")
+	}
+
+	isPlayer := (chess.WhitePlayerID != nil && *chess.WhitePlayerID == userID) || (chess.BlackPlayerID != nil && *chess.BlackPlayerID == userID)
+
+	if !isPlayer || (chess.CreatedByID != nil && *chess.CreatedByID == userID) {
+		return nil, errs.BadRequestErr().Msg("only the challenged player can answer the challenge")
+	}
+
+	if chess.ChallengeExpiresAt.Before(time.Now()) {
+		return nil, errs.BadRequestErr().Msg("the challenge has expired")
+	}
+
+	return &chess, nil
+}
+
+// newChallengeOutput returns the challenge of the game with its players preloaded, nil when it is not a challenge
+func newChallengeOutput(chess *models.Chess) *appModels.ChessChallengeOutputModel {
+	if !chess.IsChallenge() || chess.WhitePlayer == nil || chess.BlackPlayer == nil {
+		return nil
+	}
+
+	challenger, opponent := chess.WhitePlayer, chess.BlackPlayer
+	color := models.ChessPlayer(models.ChessPlayerWhite)
+
+	if chess.CreatedByID != nil && *chess.CreatedByID == chess.BlackPlayer.ID {
+		challenger, opponent = opponent, challenger
+		color = models.ChessPlayerBlack
+	}
+
+	return &appModels.ChessChallengeOutputModel{
+		ChessID:    chess.ID,
+		Challenger: newChessPlayerOutput(challenger),
+		Opponent:   newChessPlayerOutput(opponent),
+		Color:      color,
+		Clock:      chess.Clock,
+		StartFEN:   chess.StartFEN,
+		CreatedAt:  chess.CreatedAt,
+		ExpiresAt:  *chess.ChallengeExpiresAt,
+	}
+}
+
+func newChessPlayerOutput(user *sharedModels.User) appModels.ChessPlayerOutputModel {
+	return appModels.ChessPlayerOutputModel{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+	}
+}
+
 func (g *ChessService) setChessCache(ctx context.Context, id uuid.UUID) (*appModels.ChessOutputModel, error) {
 	db := psql.DBContext(ctx)
 
@@ -182,10 +591,22 @@ func (g *ChessService) setChessCache(ctx context.Context, id uuid.UUID) (*appMod
 		Turn:          chess.Turn,
 		Moves:         chess.Moves,
 		Pieces:        chess.Pieces,
+		FEN:           chess.FEN,
 		Status:        chess.Status,
 		Winner:        chess.WinnerID,
+		Result:        chess.Result,
+		Termination:   chess.Termination,
+		ComputerLevel: chess.ComputerLevel,
+		StartFEN:      chess.StartFEN,
+		ECO:           chess.ECO,
+		Opening:       chess.Opening,
+		Clock:         chess.Clock,
 		WhitePlayerID: chess.WhitePlayerID,
 		BlackPlayerID: chess.BlackPlayerID,
+
+		ChallengeExpiresAt: chess.ChallengeExpiresAt,
+		DeclineReason:      chess.DeclineReason,
+		RematchID:          chess.RematchID,
 	}
 
 	if chess.WhitePlayer != nil {
@@ -233,7 +654,18 @@ func (g *ChessService) SetWatcher(chessID, userID uuid.UUID) error {
 func (g *ChessService) NewChess(ctx context.Context, currentUser *sharedModels.User, req *appModels.CreateChessInputModel) (*models.Chess, error) {
 	db := psql.DBContext(ctx)
 
-	chessboard := chessboard.NewDefault()
+	board := chessboard.NewDefault()
+
+	startFEN, err := req.StartFEN()
+	if err != nil {
+		return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+	}
+
+	if startFEN != "" {
+		if board, err = chessboard.NewFromFEN(startFEN); err != nil {
+			return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+		}
+	}
 
 	var (
 		whitePlayer, blackPlayer *sharedModels.User
@@ -245,9 +677,16 @@ func (g *ChessService) NewChess(ctx context.Context, currentUser *sharedModels.U
 		blackPlayer = currentUser
 	}
 
-	if req.PlayingWith != nil {
+	opponentID := req.PlayingWith
+
+	// the computer plays as its own system user
+	if req.ComputerLevel != nil {
+		opponentID = &models.ComputerUserID
+	}
 
-		opponetUser, err := g.userService.Get(ctx, *req.PlayingWith)
+	if opponentID != nil {
+
+		opponetUser, err := g.userService.Get(ctx, *opponentID)
 
 		if err != nil {
 			return nil, err
@@ -260,7 +699,273 @@ func (g *ChessService) NewChess(ctx context.Context, currentUser *sharedModels.U
 		}
 	}
 
-	chess := models.NewChess(whitePlayer, blackPlayer, chessboard.GetPieces())
+	chess := models.NewChess(whitePlayer, blackPlayer, board)
+	chess.ComputerLevel = req.ComputerLevel
+	chess.CreatedByID = &currentUser.ID
+
+	// a user opponent has to accept the challenge before the game opens
+	if req.PlayingWith != nil && req.ComputerLevel == nil {
+		expiresAt := time.Now().Add(challengeTTL())
+
+		chess.Status = models.ChessStatusWaiting
+		chess.ChallengeExpiresAt = &expiresAt
+	}
+
+	if startFEN != "" {
+		chess.StartFEN = &startFEN
+	}
+
+	if req.TimeControl != nil {
+		chess.Clock = models.NewChessClock(req.TimeControl.TimeControl())
+	}
+
+	if err := db.Create(chess).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	return chess, nil
+}
+
+// RehashGames fills the position hash of the games stored before it was
+// kept, the position comes from the FEN or else from replaying the moves
+func (g *ChessService) RehashGames(ctx context.Context) (int, error) {
+	db := psql.DBContext(ctx)
+
+	var (
+		games   []models.Chess
+		updated = 0
+	)
+
+	err := db.Where("hash IS NULL").FindInBatches(&games, 100, func(tx *gorm.DB, batch int) error {
+		for _, chess := range games {
+			board, err := chessPosition(&chess)
+			if err != nil {
+				logging.ErrorE("failed to rebuild the chess position", err, "chessId", chess.ID)
+				continue
+			}
+
+			if err := tx.Model(&chess).UpdateColumn("hash", models.ChessHash(board.Hash())).Error; err != nil {
+				return err
+			}
+
+			updated++
+		}
+
+		return nil
+	}).Error
+
+	if err != nil {
+		return updated, errs.InternalServerErr().WithError(err)
+	}
+
+	return updated, nil
+}
+
+// chessPosition rebuilds the current position of the stored game
+func chessPosition(chess *models.Chess) (*chessboard.Chessboard, error) {
+	if chess.FEN != "" {
+		return chessboard.NewFromFEN(chess.FEN)
+	}
+
+	moves := make([]*chessboard.ChessBoardMove, len(chess.Moves))
+
+	for i, chessMove := range chess.Moves {
+		move, err := chessMove.ToChessBoardMove()
+		if err != nil {
+			return nil, err
+		}
+
+		moves[i] = move
+	}
+
+	return chessboard.NewFromFENAndMoves(chess.StartingFEN(), moves)
+}
+
+// GetPosition returns the position of the game after the ply and the move
+// that reached it, the current position when ply is nil. The move is nil in
+// the start position.
+func (g *ChessService) GetPosition(ctx context.Context, currentUser *sharedModels.User, id uuid.UUID, ply *int) (*chessboard.Chessboard, *chessboard.ChessBoardMove, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", id).Error; err != nil {
+		return nil, nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if err := checkGameInProgress(&chess, currentUser, "render"); err != nil {
+		return nil, nil, err
+	}
+
+	moves := chess.Moves
+
+	if ply != nil {
+		if *ply > len(moves) {
+			return nil, nil, errs.BadRequestErr().Msg(fmt.Sprintf("the game has %d moves", len(moves)))
+		}
+
+		moves = moves[:*ply]
+	}
+
+	board, err := chessboard.NewFromFEN(chess.StartingFEN())
+	if err != nil {
+		return nil, nil, errs.InternalServerErr().WithError(err)
+	}
+
+	var lastMove *chessboard.ChessBoardMove
+
+	for i, chessMove := range moves {
+		move, err := chessMove.ToChessBoardMove()
+		if err != nil {
+			return nil, nil, errs.InternalServerErr().WithError(err)
+		}
+
+		if lastMove, err = board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+			return nil, nil, errs.InternalServerErr().WithError(fmt.Errorf("move %d: %w", i+1, err))
+		}
+	}
+
+	return board, lastMove, nil
+}
+
+// ExportPGN returns the game with the Seven Tag Roster and its moves in standard algebraic notation
+func (g *ChessService) ExportPGN(ctx context.Context, currentUser *sharedModels.User, id uuid.UUID) (*pgn.Game, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.Preload("WhitePlayer").Preload("BlackPlayer").First(&chess, "id = ?", id).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if err := checkGameInProgress(&chess, currentUser, "export"); err != nil {
+		return nil, err
+	}
+
+	game := &pgn.Game{}
+	game.SetTag("Event", "Casual game")
+	game.SetTag("Site", "?")
+	game.SetTag("Date", chess.CreatedAt.Format("2006.01.02"))
+	game.SetTag("Round", "-")
+	game.SetTag("White", pgnPlayerName(chess.WhitePlayer))
+	game.SetTag("Black", pgnPlayerName(chess.BlackPlayer))
+	game.SetTag("Result", pgn.ResultUnknown)
+
+	if chess.Result != nil {
+		game.SetTag("Result", string(*chess.Result))
+	}
+
+	if chess.ECO != nil && chess.Opening != nil {
+		game.SetTag("ECO", *chess.ECO)
+		game.SetTag("Opening", *chess.Opening)
+	}
+
+	// a game set up from a position carries it in the FEN tag
+	if chess.StartFEN != nil {
+		game.SetTag("SetUp", "1")
+		game.SetTag("FEN", *chess.StartFEN)
+	}
+
+	board, err := chessboard.NewFromFEN(chess.StartingFEN())
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	for i, chessMove := range chess.Moves {
+		move, err := chessMove.ToChessBoardMove()
+		if err != nil {
+			return nil, errs.InternalServerErr().WithError(err)
+		}
+
+		san, err := board.SAN(move.From, move.To, move.Promotion)
+		if err != nil {
+			return nil, errs.InternalServerErr().WithError(fmt.Errorf("move %d: %w", i+1, err))
+		}
+
+		if _, err := board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+			return nil, errs.InternalServerErr().WithError(fmt.Errorf("move %d: %w", i+1, err))
+		}
+
+		game.Moves = append(game.Moves, san)
+	}
+
+	return game, nil
+}
+
+// ImportPGN creates a finished game from the first game of the PGN, the
+// current user takes the side whose PGN player name is their username
+func (g *ChessService) ImportPGN(ctx context.Context, currentUser *sharedModels.User, r io.Reader) (*models.Chess, error) {
+	db := psql.DBContext(ctx)
+
+	games, err := pgn.Parse(r)
+	if err != nil {
+		return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+	}
+
+	game := games[0]
+
+	// the moves are validated while they are played on the board
+	board, moves, err := game.Replay()
+	if err != nil {
+		return nil, errs.BadRequestErr().Msg(err.Error()).WithError(err)
+	}
+
+	var whitePlayer, blackPlayer *sharedModels.User
+
+	if game.GetTag("White") == currentUser.Username {
+		whitePlayer = currentUser
+	} else if game.GetTag("Black") == currentUser.Username {
+		blackPlayer = currentUser
+	}
+
+	chess := models.NewChess(whitePlayer, blackPlayer, board)
+	chess.Status = models.ChessStatusClose
+	chess.CreatedByID = &currentUser.ID
+
+	if fen := game.GetTag("FEN"); fen != "" {
+		chess.StartFEN = &fen
+	}
+	chess.Turn = models.GetChessPlayerFromColor(board.Turn)
+	chess.SetOpening(eco.Classify(board))
+
+	color := board.Turn
+	if len(moves)%2 == 1 {
+		color = chessboard.White
+		if board.Turn == chessboard.White {
+			color = chessboard.Black
+		}
+	}
+
+	for _, move := range moves {
+		chess.Moves = append(chess.Moves, models.NewChessMove(color, move))
+
+		if color == chessboard.White {
+			color = chessboard.Black
+		} else {
+			color = chessboard.White
+		}
+	}
+
+	// the final position decides the termination, the result tag the rest
+	if evaluation := board.EvaluateResult(board.Turn); evaluation != nil {
+		result := models.NewChessResult(evaluation.Winner)
+		termination := models.ChessTermination(evaluation.Termination)
+
+		chess.Result = &result
+		chess.Termination = &termination
+	} else if gameResult := game.Result(); gameResult != pgn.ResultUnknown {
+		result := models.ChessResult(gameResult)
+		chess.Result = &result
+	}
+
+	if chess.Result != nil {
+		switch *chess.Result {
+		case models.ChessResultWhiteWon:
+			chess.WinnerID = chess.WhitePlayerID
+		case models.ChessResultBlackWon:
+			chess.WinnerID = chess.BlackPlayerID
+		}
+	}
 
 	if err := db.Create(chess).Error; err != nil {
 		return nil, errs.InternalServerErr().WithError(err)
@@ -269,6 +974,14 @@ func (g *ChessService) NewChess(ctx context.Context, currentUser *sharedModels.U
 	return chess, nil
 }
 
+func pgnPlayerName(user *sharedModels.User) string {
+	if user == nil {
+		return "?"
+	}
+
+	return user.Username
+}
+
 func (g *ChessService) DeleteWatcherCache(userID uuid.UUID) error {
 	return g.cache.Delete(g.getUserChessWatcherCacheKey(userID))
 }
@@ -280,3 +993,23 @@ func (g *ChessService) getUserChessWatcherCacheKey(userID uuid.UUID) string {
 func (g *ChessService) getChessCacheKey(id uuid.UUID) string {
 	return "chess_" + id.String()
 }
+
+func removeChessPiece(pieces models.ChessPieces, position chessboard.Position) models.ChessPieces {
+	for i, piece := range pieces {
+		if piece.Row == position.Row && piece.Col == position.Col {
+			return util.ArrayRemoveIndex[models.ChessPiece](pieces, i)
+		}
+	}
+
+	return pieces
+}
+
+// checkGameInProgress refuses a game in progress to its players, like the
+// analysis does. Anybody can watch a game, so the rest of the games are public.
+func checkGameInProgress(chess *models.Chess, user *sharedModels.User, action string) error {
+	if chess.Status == models.ChessStatusOpen && isChessPlayer(chess, user) {
+		return errs.BadRequestErr().Msg(fmt.Sprintf("you can not %s your game before it is over", action))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/engine"
+	"github.com/esmailemami/chess/game/pkg/uci"
+	"github.com/esmailemami/chess/shared/logging"
+	"github.com/spf13/viper"
+)
+
+// uciPool runs the external engine of the config, nil when the built-in engine searches
+var uciPool *uci.Pool
+
+// StartEngine starts the external UCI engine when it is enabled in the config
+func StartEngine() {
+	if !viper.GetBool("uci.enable") {
+		return
+	}
+
+	pool, err := uci.NewPool(uciConfig())
+	if err != nil {
+		logging.ErrorE("failed to start the uci engine, the built-in engine searches instead", err)
+		return
+	}
+
+	uciPool = pool
+}
+
+func uciConfig() uci.Config {
+	config := uci.Config{
+		Path:      viper.GetString("uci.path"),
+		Args:      viper.GetStringSlice("uci.args"),
+		Processes: viper.GetInt("uci.processes"),
+		Threads:   viper.GetInt("uci.threads"),
+		Hash:      viper.GetInt("uci.hash"),
+		Timeout:   viper.GetDuration("uci.timeout"),
+	}
+
+	if viper.IsSet("uci.skill") {
+		skill := viper.GetInt("uci.skill")
+		config.Skill = &skill
+	}
+
+	return config
+}
+
+// SearchPosition searches the board with the external engine when one runs
+// and with the built-in engine otherwise
+func SearchPosition(ctx context.Context, board *chessboard.Chessboard, opts engine.Options) (*engine.Result, error) {
+	return searchPosition(ctx, board, opts, nil)
+}
+
+// SearchComputerMove searches the move of a computer level, the noise weakens
+// the built-in engine and the Skill Level from 0 to 20 the external one
+func SearchComputerMove(ctx context.Context, board *chessboard.Chessboard, opts engine.Options, skill int) (*engine.Result, error) {
+	return searchPosition(ctx, board, opts, &skill)
+}
+
+func searchPosition(ctx context.Context, board *chessboard.Chessboard, opts engine.Options, skill *int) (*engine.Result, error) {
+	if uciPool == nil {
+		return engine.Search(ctx, board, opts)
+	}
+
+	result, err := uciPool.Search(ctx, &uci.Request{
+		FEN:      board.FEN(),
+		Depth:    opts.Depth,
+		MoveTime: opts.MoveTime,
+		MultiPV:  opts.MultiPV,
+		Skill:    skill,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newEngineResult(result)
+}
+
+// newEngineResult converts the answer of the external engine to the result of the built-in one
+func newEngineResult(result *uci.Result) (*engine.Result, error) {
+	bestMove, err := chessboard.ParseUCI(result.BestMove)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &engine.Result{
+		Move: bestMove,
+		PV:   []*chessboard.ChessBoardMove{bestMove},
+	}
+
+	for _, uciLine := range result.Lines {
+		line := engine.Line{
+			Score: uciLine.Score,
+			PV:    make([]*chessboard.ChessBoardMove, 0, len(uciLine.PV)),
+		}
+
+		if uciLine.Mate != 0 {
+			line.Score = engine.MateScore(uciLine.Mate)
+		}
+
+		for _, uciMove := range uciLine.PV {
+			move, err := chessboard.ParseUCI(uciMove)
+			if err != nil {
+				return nil, err
+			}
+
+			line.PV = append(line.PV, move)
+		}
+
+		// a line of an interrupted iteration may be missing
+		if len(line.PV) == 0 {
+			continue
+		}
+
+		// the depth and nodes are the ones of the best line that was kept
+		if len(output.Lines) == 0 {
+			output.Depth, output.Nodes = uciLine.Depth, uciLine.Nodes
+		}
+
+		line.Move = line.PV[0]
+		output.Lines = append(output.Lines, line)
+	}
+
+	if len(output.Lines) == 0 {
+		output.Lines = []engine.Line{{Move: bestMove, PV: output.PV}}
+	} else {
+		output.Score, output.PV = output.Lines[0].Score, output.Lines[0].PV
+	}
+
+	return output, nil
+}
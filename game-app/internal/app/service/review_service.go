@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	appModels "github.com/esmailemami/chess/game/internal/app/models"
+	"github.com/esmailemami/chess/game/internal/models"
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/engine"
+	"github.com/esmailemami/chess/shared/database/psql"
+	"github.com/esmailemami/chess/shared/errs"
+	sharedModels "github.com/esmailemami/chess/shared/models"
+	"github.com/google/uuid"
+)
+
+// reviewOptions is the budget of every position of a review, a game has a lot of them
+var reviewOptions = engine.Options{
+	Depth:    12,
+	MoveTime: 300 * time.Millisecond,
+}
+
+type ReviewService struct{}
+
+func NewReviewService() *ReviewService {
+	return &ReviewService{}
+}
+
+// GetReview returns the review of the game
+func (r *ReviewService) GetReview(ctx context.Context, currentUser *sharedModels.User, chessID uuid.UUID) (*appModels.ChessReviewOutputModel, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.Select("id", "status", "white_player_id", "black_player_id").First(&chess, "id = ?", chessID).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if err := checkGameInProgress(&chess, currentUser, "review"); err != nil {
+		return nil, err
+	}
+
+	review, err := r.getReview(ctx, chessID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newChessReviewOutput(review), nil
+}
+
+func (r *ReviewService) getReview(ctx context.Context, chessID uuid.UUID) (*models.ChessReview, error) {
+	db := psql.DBContext(ctx)
+
+	var review models.ChessReview
+
+	if err := db.First(&review, "chess_id = ?", chessID).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	return &review, nil
+}
+
+func newChessReviewOutput(review *models.ChessReview) *appModels.ChessReviewOutputModel {
+	return &appModels.ChessReviewOutputModel{
+		ChessID:       review.ChessID,
+		Moves:         review.Moves,
+		WhiteAccuracy: review.WhiteAccuracy,
+		BlackAccuracy: review.BlackAccuracy,
+	}
+}
+
+// ReviewGame evaluates every position of the finished game, tags every move by
+// the centipawns it lost and rates the accuracy of both players. A game is only reviewed once.
+func (r *ReviewService) ReviewGame(ctx context.Context, chessID uuid.UUID) (*appModels.ChessReviewOutputModel, error) {
+	db := psql.DBContext(ctx)
+
+	var chess models.Chess
+
+	if err := db.First(&chess, "id = ?", chessID).Error; err != nil {
+		return nil, errs.NotFoundErr().WithError(err)
+	}
+
+	if chess.Status != models.ChessStatusClose {
+		return nil, errs.BadRequestErr().Msg("the game is not over yet")
+	}
+
+	if review, err := r.getReview(ctx, chessID); err == nil {
+		return newChessReviewOutput(review), nil
+	}
+
+	moves, err := reviewMoves(ctx, chess.StartingFEN(), chess.Moves)
+	if err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	review := &models.ChessReview{
+		ChessID:       chessID,
+		Moves:         moves,
+		WhiteAccuracy: playerAccuracy(moves, models.ChessPlayerWhite),
+		BlackAccuracy: playerAccuracy(moves, models.ChessPlayerBlack),
+	}
+	review.ID = uuid.New()
+
+	if err := db.Create(review).Error; err != nil {
+		return nil, errs.InternalServerErr().WithError(err)
+	}
+
+	return newChessReviewOutput(review), nil
+}
+
+// positionEval is the evaluation of a position from the point of view of the side to move
+type positionEval struct {
+	score   int
+	best    *chessboard.ChessBoardMove
+	bestSAN string
+}
+
+// reviewMoves replays the game from the start position of the FEN and
+// compares the evaluation before every move with the one after it
+func reviewMoves(ctx context.Context, fen string, chessMoves models.ChessMoves) (models.ChessReviewMoves, error) {
+	board, err := chessboard.NewFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := make(models.ChessReviewMoves, len(chessMoves))
+
+	before, err := evaluatePosition(ctx, board)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, chessMove := range chessMoves {
+		move, err := chessMove.ToChessBoardMove()
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			turn   = board.Turn
+			played *chessboard.ChessBoardMove
+		)
+
+		if piece := board.GetPiece(move.From.Row, move.From.Col); piece == nil {
+			return nil, errors.New("there is no piece to replay the move with")
+		} else if played, err = board.PlacePiece(piece, move.To, move.Promotion); err != nil {
+			return nil, err
+		}
+
+		after, err := evaluatePosition(ctx, board)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			scoreBefore = engine.ClampScore(before.score)
+			scoreAfter  = engine.ClampScore(-after.score)
+			loss        = max(0, scoreBefore-scoreAfter)
+			isBest      = before.best != nil && before.best.UCI() == played.UCI()
+		)
+
+		moves[i] = models.ChessReviewMove{
+			Ply:            i + 1,
+			Player:         models.GetChessPlayerFromColor(turn),
+			SAN:            played.SAN,
+			BestMove:       before.bestSAN,
+			Score:          whiteReviewScore(turn, scoreAfter),
+			CentipawnLoss:  loss,
+			Accuracy:       engine.MoveAccuracy(engine.WinPercent(scoreBefore), engine.WinPercent(scoreAfter)),
+			Classification: models.ClassifyChessMove(loss, isBest),
+		}
+
+		before = after
+	}
+
+	return moves, nil
+}
+
+func evaluatePosition(ctx context.Context, board *chessboard.Chessboard) (*positionEval, error) {
+	// the game ended in the position, by checkmate or stalemate
+	if len(board.GetAllValidMoves(board.Turn)) == 0 {
+		if board.IsInCheck(board.Turn) {
+			return &positionEval{score: engine.Mated}, nil
+		}
+
+		return &positionEval{score: 0}, nil
+	}
+
+	result, err := SearchPosition(ctx, board, reviewOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	san, err := board.SAN(result.Move.From, result.Move.To, result.Move.Promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &positionEval{score: result.Score, best: result.Move, bestSAN: san}, nil
+}
+
+// whiteReviewScore turns the score of the player who moved into the score of white
+func whiteReviewScore(turn chessboard.Color, score int) int {
+	if turn == chessboard.Black {
+		return -score
+	}
+	return score
+}
+
+// playerAccuracy is the average accuracy of the moves of the player rounded to two decimals
+func playerAccuracy(moves models.ChessReviewMoves, player models.ChessPlayer) float64 {
+	var (
+		total float64
+		count int
+	)
+
+	for _, move := range moves {
+		if move.Player == player {
+			total += move.Accuracy
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return math.Round(total/float64(count)*100) / 100
+}
@@ -3,8 +3,11 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/eco"
 	"github.com/esmailemami/chess/shared/models"
 	"github.com/google/uuid"
 )
@@ -18,6 +21,98 @@ const (
 	ChessStatusClose
 )
 
+type ChessResult string
+
+const (
+	ChessResultWhiteWon ChessResult = "1-0"
+	ChessResultBlackWon ChessResult = "0-1"
+	ChessResultDraw     ChessResult = "1/2-1/2"
+)
+
+// NewChessResult returns the result of a game won by the winner color, a nil winner is a draw
+func NewChessResult(winner *chessboard.Color) ChessResult {
+	if winner == nil {
+		return ChessResultDraw
+	}
+
+	if *winner == chessboard.White {
+		return ChessResultWhiteWon
+	}
+	return ChessResultBlackWon
+}
+
+type ChessTermination string
+
+const (
+	ChessTerminationCheckmate            ChessTermination = "checkmate"
+	ChessTerminationStalemate            ChessTermination = "stalemate"
+	ChessTerminationInsufficientMaterial ChessTermination = "insufficient_material"
+	ChessTerminationFivefoldRepetition   ChessTermination = "fivefold_repetition"
+	ChessTerminationSeventyFiveMoveRule  ChessTermination = "seventy_five_move_rule"
+	ChessTerminationThreefoldRepetition  ChessTermination = "threefold_repetition"
+	ChessTerminationFiftyMoveRule        ChessTermination = "fifty_move_rule"
+
+	// a player ran out of time, it is a draw when the opponent can not mate
+	ChessTerminationTimeout                       ChessTermination = "timeout"
+	ChessTerminationTimeoutVsInsufficientMaterial ChessTermination = "timeout_vs_insufficient_material"
+
+	// decided by the players
+	ChessTerminationResignation ChessTermination = "resignation"
+	ChessTerminationAgreement   ChessTermination = "agreement"
+
+	// a player left the game, it is aborted when the game ends before a player moved
+	ChessTerminationAbandoned ChessTermination = "abandoned"
+	ChessTerminationAborted   ChessTermination = "aborted"
+	ChessTerminationCancelled ChessTermination = "cancelled"
+
+	// the challenged player did not answer the challenge in time
+	ChessTerminationExpired ChessTermination = "expired"
+)
+
+// ChessHandicap is a piece the player giving the odds starts the game without
+type ChessHandicap string
+
+const (
+	ChessHandicapPawn   ChessHandicap = "pawn"
+	ChessHandicapKnight ChessHandicap = "knight"
+	ChessHandicapQueen  ChessHandicap = "queen"
+)
+
+// chessHandicapFENs are the start positions of the handicaps by the color
+// giving the odds, it plays without the f-pawn, the queen's knight or the queen
+var chessHandicapFENs = map[ChessHandicap]map[chessboard.Color]string{
+	ChessHandicapPawn: {
+		chessboard.White: "rnbqkbnr/pppppppp/8/8/8/8/PPPPP1PP/RNBQKBNR w KQkq - 0 1",
+		chessboard.Black: "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	},
+	ChessHandicapKnight: {
+		chessboard.White: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1",
+		chessboard.Black: "r1bqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	},
+	ChessHandicapQueen: {
+		chessboard.White: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1",
+		chessboard.Black: "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	},
+}
+
+// FEN returns the start position of the handicap given by the color
+func (h ChessHandicap) FEN(color chessboard.Color) (string, error) {
+	fens, ok := chessHandicapFENs[h]
+	if !ok {
+		return "", fmt.Errorf("unknown handicap %q", h)
+	}
+
+	return fens[color], nil
+}
+
+// ComputerUserID is the system user the built-in computer opponent plays as
+var ComputerUserID = models.USER_COMPUTER
+
+const (
+	MinComputerLevel = 1
+	MaxComputerLevel = 8
+)
+
 type ChessPlayer string
 
 const (
@@ -44,41 +139,59 @@ func (g ChessPlayer) ChessColor() chessboard.Color {
 type Chess struct {
 	models.Model
 
-	WhitePlayerID *uuid.UUID   `gorm:"white_player_id" json:"whitePlayerId"`
-	WhitePlayer   *models.User `gorm:"foreignKey:white_player_id;references:id" json:"whitePlayer"`
-	BlackPlayerID *uuid.UUID   `gorm:"black_player_id" json:"blackPlayerId"`
-	BlackPlayer   *models.User `gorm:"foreignKey:black_player_id;references:id" json:"blackPlayer"`
-	Turn          ChessPlayer  `gorm:"turn" json:"turn"`
-	Moves         ChessMoves   `gorm:"moves" json:"moves"`
-	Pieces        ChessPieces  `gorm:"pieces" json:"pieces"`
-	Status        ChessStatus  `gorm:"status" json:"status"`
-	WinnerID      *uuid.UUID   `gorm:"winner_id" json:"winnerId"`
-	Winner        *models.User `gorm:"foreignKey:winner_id;references:id" json:"winner"`
+	WhitePlayerID *uuid.UUID        `gorm:"white_player_id" json:"whitePlayerId"`
+	WhitePlayer   *models.User      `gorm:"foreignKey:white_player_id;references:id" json:"whitePlayer"`
+	BlackPlayerID *uuid.UUID        `gorm:"black_player_id" json:"blackPlayerId"`
+	BlackPlayer   *models.User      `gorm:"foreignKey:black_player_id;references:id" json:"blackPlayer"`
+	Turn          ChessPlayer       `gorm:"turn" json:"turn"`
+	Moves         ChessMoves        `gorm:"moves" json:"moves"`
+	Pieces        ChessPieces       `gorm:"pieces" json:"pieces"`
+	FEN           string            `gorm:"column:fen" json:"fen"`
+	Hash          *int64            `gorm:"column:hash" json:"-"`
+	Status        ChessStatus       `gorm:"status" json:"status"`
+	WinnerID      *uuid.UUID        `gorm:"winner_id" json:"winnerId"`
+	Winner        *models.User      `gorm:"foreignKey:winner_id;references:id" json:"winner"`
+	Result        *ChessResult      `gorm:"result" json:"result"`
+	Termination   *ChessTermination `gorm:"termination" json:"termination"`
+	ComputerLevel *int              `gorm:"column:computer_level" json:"computerLevel"`
+	StartFEN      *string           `gorm:"column:start_fen" json:"startFen"`
+	Clock         *ChessClock       `gorm:"column:clock" json:"clock"`
+	ECO           *string           `gorm:"column:eco" json:"eco"`
+	Opening       *string           `gorm:"column:opening" json:"opening"`
+
+	// ChallengeExpiresAt is set for a game the creator challenged the opponent to,
+	// DeclineReason is what the opponent gave when it was rejected
+	ChallengeExpiresAt *time.Time `gorm:"column:challenge_expires_at" json:"challengeExpiresAt"`
+	DeclineReason      *string    `gorm:"column:decline_reason" json:"declineReason"`
+
+	// RematchID is the game the players started after this one
+	RematchID *uuid.UUID `gorm:"column:rematch_id" json:"rematchId"`
 }
 
 func (Chess) TableName() string {
 	return "game.chess"
 }
 
-func NewChess(whitePlayer, blackPlayer *models.User, pieces []*chessboard.ChessboardPiece) *Chess {
+func NewChess(whitePlayer, blackPlayer *models.User, board *chessboard.Chessboard) *Chess {
 	chess := &Chess{
 		WhitePlayer: whitePlayer,
 		BlackPlayer: blackPlayer,
 		Status:      ChessStatusWaiting,
-		Turn:        ChessPlayerWhite,
-		Pieces:      make(ChessPieces, len(pieces)),
+		Pieces:      NewChessPieces(board.GetPieces()),
 		Moves:       make(ChessMoves, 0),
 	}
 	chess.ID = uuid.New()
+	chess.SetPosition(board)
+
+	// a game set up from a position may start with black to move
+	chess.Turn = GetChessPlayerFromColor(board.Turn)
 
 	if whitePlayer != nil {
 		chess.WhitePlayerID = &whitePlayer.ID
-		chess.Turn = ChessPlayerWhite
 	}
 
 	if blackPlayer != nil {
 		chess.BlackPlayerID = &blackPlayer.ID
-		chess.Turn = ChessPlayerBlack
 	}
 
 	// the Chess is accepted and open
@@ -86,17 +199,46 @@ func NewChess(whitePlayer, blackPlayer *models.User, pieces []*chessboard.Chessb
 		chess.Status = ChessStatusOpen
 	}
 
-	// set default pieces
-	for i, piece := range pieces {
-		chess.Pieces[i] = ChessPiece{
-			Row:    piece.Row,
-			Col:    piece.Col,
-			Piece:  string(piece.PieceType),
-			Player: GetChessPlayerFromColor(piece.Color),
-		}
+	return chess
+}
+
+// IsChallenge reports whether the game waits for the challenged player to accept it
+func (g *Chess) IsChallenge() bool {
+	return g.ChallengeExpiresAt != nil && g.Status == ChessStatusWaiting
+}
+
+// StartingFEN returns the position the game started from
+func (g *Chess) StartingFEN() string {
+	if g.StartFEN == nil {
+		return chessboard.DefaultFEN
 	}
 
-	return chess
+	return *g.StartFEN
+}
+
+// SetPosition stores the FEN and the zobrist hash of the current position of the board
+func (g *Chess) SetPosition(board *chessboard.Chessboard) {
+	hash := ChessHash(board.Hash())
+
+	g.FEN = board.FEN()
+	g.Hash = &hash
+}
+
+// SetOpening stores the classification of the game, a nil opening clears it
+func (g *Chess) SetOpening(opening *eco.Opening) {
+	if opening == nil {
+		g.ECO = nil
+		g.Opening = nil
+		return
+	}
+
+	g.ECO = &opening.ECO
+	g.Opening = &opening.Name
+}
+
+// ChessHash converts a zobrist hash to the signed bigint of the hash column
+func ChessHash(hash uint64) int64 {
+	return int64(hash)
 }
 
 func (g *Chess) SwitchTurn() {
@@ -109,9 +251,43 @@ func (g *Chess) SwitchTurn() {
 
 // ChessMoves
 type ChessMove struct {
-	Player ChessPlayer
-	From   string // Example: a1
-	To     string // Example: a2
+	Player    ChessPlayer
+	From      string // Example: e2
+	To        string // Example: e4
+	Promotion string `json:",omitempty"` // Example: Q
+	Castling  string `json:",omitempty"` // Example: O-O
+	SAN       string `json:",omitempty"` // Example: Nxe5+
+}
+
+func NewChessMove(color chessboard.Color, move *chessboard.ChessBoardMove) ChessMove {
+	return ChessMove{
+		Player:    GetChessPlayerFromColor(color),
+		From:      move.From.String(),
+		To:        move.To.String(),
+		Promotion: string(move.Promotion),
+		Castling:  string(move.Castling),
+		SAN:       move.SAN,
+	}
+}
+
+func (m *ChessMove) ToChessBoardMove() (*chessboard.ChessBoardMove, error) {
+	from, err := chessboard.GetPosition(m.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := chessboard.GetPosition(m.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chessboard.ChessBoardMove{
+		From:      *from,
+		To:        *to,
+		Promotion: chessboard.PieceType(m.Promotion),
+		Castling:  chessboard.Castling(m.Castling),
+		SAN:       m.SAN,
+	}, nil
 }
 
 type ChessMoves []ChessMove
@@ -158,6 +334,21 @@ func (p *ChessPiece) ToChessPiece() *chessboard.ChessboardPiece {
 
 type ChessPieces []ChessPiece
 
+func NewChessPieces(pieces []*chessboard.ChessboardPiece) ChessPieces {
+	chessPieces := make(ChessPieces, len(pieces))
+
+	for i, piece := range pieces {
+		chessPieces[i] = ChessPiece{
+			Row:    piece.Row,
+			Col:    piece.Col,
+			Piece:  string(piece.PieceType),
+			Player: GetChessPlayerFromColor(piece.Color),
+		}
+	}
+
+	return chessPieces
+}
+
 func (p ChessPieces) Value() (driver.Value, error) {
 	valueString, err := json.Marshal(p)
 	return string(valueString), err
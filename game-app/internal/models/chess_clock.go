@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+	"github.com/esmailemami/chess/game/pkg/clock"
+)
+
+// ChessClock is the time control of the game and the time left on the clocks, the times are in milliseconds
+type ChessClock struct {
+	Initial   int64 `json:"initial"`
+	Increment int64 `json:"increment"`
+	Delay     int64 `json:"delay"`
+
+	// White and Black are the time left when the turn of the side to move started
+	White int64 `json:"white"`
+	Black int64 `json:"black"`
+
+	// TurnStartedAt is when the clock of the side to move started, nil while the clocks do not run
+	TurnStartedAt *time.Time `json:"turnStartedAt"`
+}
+
+// NewChessClock returns a clock with the initial time on both sides
+func NewChessClock(control clock.TimeControl) *ChessClock {
+	return &ChessClock{
+		Initial:   control.Initial.Milliseconds(),
+		Increment: control.Increment.Milliseconds(),
+		Delay:     control.Delay.Milliseconds(),
+		White:     control.Initial.Milliseconds(),
+		Black:     control.Initial.Milliseconds(),
+	}
+}
+
+// SetClock stores the state of the running clock
+func (c *ChessClock) SetClock(gameClock *clock.Clock) {
+	state := gameClock.State()
+
+	c.White = state.White.Milliseconds()
+	c.Black = state.Black.Milliseconds()
+	c.TurnStartedAt = state.TurnStartedAt
+}
+
+func (c *ChessClock) TimeControl() clock.TimeControl {
+	return clock.TimeControl{
+		Initial:   time.Duration(c.Initial) * time.Millisecond,
+		Increment: time.Duration(c.Increment) * time.Millisecond,
+		Delay:     time.Duration(c.Delay) * time.Millisecond,
+	}
+}
+
+// Clock restores the running clock, turn is the side to move and plies the number of moves played
+func (c *ChessClock) Clock(turn chessboard.Color, plies int) *clock.Clock {
+	return clock.Restore(c.TimeControl(), clock.State{
+		White:         time.Duration(c.White) * time.Millisecond,
+		Black:         time.Duration(c.Black) * time.Millisecond,
+		Turn:          turn,
+		TurnStartedAt: c.TurnStartedAt,
+	}, plies)
+}
+
+func (c ChessClock) Value() (driver.Value, error) {
+	valueString, err := json.Marshal(c)
+	return string(valueString), err
+}
+
+func (c *ChessClock) Scan(value interface{}) error {
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	case nil:
+		return nil
+	}
+	return json.Unmarshal(bts, c)
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/esmailemami/chess/shared/models"
+	"github.com/google/uuid"
+)
+
+type ChessMoveClassification string
+
+const (
+	ChessMoveBest       ChessMoveClassification = "best"
+	ChessMoveGood       ChessMoveClassification = "good"
+	ChessMoveInaccuracy ChessMoveClassification = "inaccuracy"
+	ChessMoveMistake    ChessMoveClassification = "mistake"
+	ChessMoveBlunder    ChessMoveClassification = "blunder"
+)
+
+// ClassifyChessMove tags a move by the centipawns it lost against the best move of the engine
+func ClassifyChessMove(centipawnLoss int, isBest bool) ChessMoveClassification {
+	switch {
+	case isBest || centipawnLoss <= 10:
+		return ChessMoveBest
+	case centipawnLoss <= 50:
+		return ChessMoveGood
+	case centipawnLoss <= 100:
+		return ChessMoveInaccuracy
+	case centipawnLoss <= 300:
+		return ChessMoveMistake
+	default:
+		return ChessMoveBlunder
+	}
+}
+
+// ChessReview is the engine review of a finished game
+type ChessReview struct {
+	models.Model
+
+	ChessID       uuid.UUID        `gorm:"column:chess_id" json:"chessId"`
+	Moves         ChessReviewMoves `gorm:"column:moves" json:"moves"`
+	WhiteAccuracy float64          `gorm:"column:white_accuracy" json:"whiteAccuracy"`
+	BlackAccuracy float64          `gorm:"column:black_accuracy" json:"blackAccuracy"`
+}
+
+func (ChessReview) TableName() string {
+	return "game.chess_review"
+}
+
+type ChessReviewMove struct {
+	Ply    int
+	Player ChessPlayer
+	SAN    string
+
+	// BestMove is the move the engine would have played, in standard algebraic notation
+	BestMove string
+
+	// Score is the evaluation after the move in centipawns from the point of view of white
+	Score int
+
+	CentipawnLoss  int
+	Accuracy       float64
+	Classification ChessMoveClassification
+}
+
+type ChessReviewMoves []ChessReviewMove
+
+func (p ChessReviewMoves) Value() (driver.Value, error) {
+	valueString, err := json.Marshal(p)
+	return string(valueString), err
+}
+
+func (j *ChessReviewMoves) Scan(value interface{}) error {
+	if value == nil {
+		j = nil
+		return nil
+	}
+	var bts []byte
+	switch v := value.(type) {
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	case nil:
+		*j = nil
+		return nil
+	}
+	return json.Unmarshal(bts, &j)
+}
@@ -0,0 +1,250 @@
+package chessboard
+
+import "math/bits"
+
+// bitboard has one bit for every square of the board, bit 0 is row 0 col 0
+// (a8) and bit 63 is row 7 col 7 (h1), so a square index is row*8 + col.
+type bitboard uint64
+
+func squareIndex(position Position) int {
+	return position.Row*8 + position.Col
+}
+
+func squarePosition(square int) Position {
+	return Position{square / 8, square % 8}
+}
+
+func squareBit(square int) bitboard {
+	return 1 << uint(square)
+}
+
+func (b bitboard) has(square int) bool {
+	return b&squareBit(square) != 0
+}
+
+func (b bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// lsb returns the lowest square of the bitboard, it must not be empty
+func (b bitboard) lsb() int {
+	return bits.TrailingZeros64(uint64(b))
+}
+
+// msb returns the highest square of the bitboard, it must not be empty
+func (b bitboard) msb() int {
+	return 63 - bits.LeadingZeros64(uint64(b))
+}
+
+// popLSB removes the lowest square of the bitboard and returns it
+func (b *bitboard) popLSB() int {
+	square := b.lsb()
+	*b &= *b - 1
+	return square
+}
+
+const (
+	pawnIndex = iota
+	knightIndex
+	bishopIndex
+	rookIndex
+	queenIndex
+	kingIndex
+)
+
+func colorIndex(color Color) int {
+	if color == White {
+		return 0
+	}
+	return 1
+}
+
+func pieceIndex(pieceType PieceType) int {
+	switch pieceType {
+	case Pawn:
+		return pawnIndex
+	case Knight:
+		return knightIndex
+	case Bishop:
+		return bishopIndex
+	case Rook:
+		return rookIndex
+	case Queen:
+		return queenIndex
+	default:
+		return kingIndex
+	}
+}
+
+// bitboards mirrors the Pieces array of the board with one bitboard for
+// every color and piece type, which turns attack lookups into a few bit operations.
+type bitboards struct {
+	pieces   [2][6]bitboard
+	occupied [2]bitboard
+}
+
+func (b *bitboards) set(piece *Piece, square int) {
+	color := colorIndex(piece.Color)
+
+	b.pieces[color][pieceIndex(piece.Type)] |= squareBit(square)
+	b.occupied[color] |= squareBit(square)
+}
+
+func (b *bitboards) clear(piece *Piece, square int) {
+	color := colorIndex(piece.Color)
+
+	b.pieces[color][pieceIndex(piece.Type)] &^= squareBit(square)
+	b.occupied[color] &^= squareBit(square)
+}
+
+func (b *bitboards) all() bitboard {
+	return b.occupied[0] | b.occupied[1]
+}
+
+// isAttacked checks if any piece of the color index attacks the square. A
+// square is attacked by a pawn when a pawn of the other color standing on it
+// would attack that pawn, the same holds for every other piece type.
+func (b *bitboards) isAttacked(square int, by int) bool {
+	var (
+		pieces   = &b.pieces[by]
+		occupied = b.all()
+	)
+
+	if knightAttacks[square]&pieces[knightIndex] != 0 ||
+		kingAttacks[square]&pieces[kingIndex] != 0 ||
+		pawnAttacks[1-by][square]&pieces[pawnIndex] != 0 {
+		return true
+	}
+
+	if bishopAttacks(square, occupied)&(pieces[bishopIndex]|pieces[queenIndex]) != 0 {
+		return true
+	}
+
+	return rookAttacks(square, occupied)&(pieces[rookIndex]|pieces[queenIndex]) != 0
+}
+
+// directions of the sliding pieces, the first four increase the square index
+const (
+	south = iota
+	east
+	southEast
+	southWest
+	north
+	west
+	northEast
+	northWest
+)
+
+var directionSteps = [8]Position{
+	south:     {1, 0},
+	east:      {0, 1},
+	southEast: {1, 1},
+	southWest: {1, -1},
+	north:     {-1, 0},
+	west:      {0, -1},
+	northEast: {-1, 1},
+	northWest: {-1, -1},
+}
+
+var (
+	// knightAttacks, kingAttacks and pawnAttacks hold the squares a piece on
+	// the square attacks, pawnAttacks is indexed by the color of the pawn first
+	knightAttacks [64]bitboard
+	kingAttacks   [64]bitboard
+	pawnAttacks   [2][64]bitboard
+
+	// rays holds the squares from the square to the edge of the board in the direction
+	rays [8][64]bitboard
+)
+
+func init() {
+	knightSteps := []Position{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+
+	for square := 0; square < 64; square++ {
+		position := squarePosition(square)
+
+		for _, step := range knightSteps {
+			if isValidArea(position.Row+step.Row, position.Col+step.Col) {
+				knightAttacks[square] |= squareBit(squareIndex(Position{position.Row + step.Row, position.Col + step.Col}))
+			}
+		}
+
+		for direction, step := range directionSteps {
+			if isValidArea(position.Row+step.Row, position.Col+step.Col) {
+				kingAttacks[square] |= squareBit(squareIndex(Position{position.Row + step.Row, position.Col + step.Col}))
+			}
+
+			for row, col := position.Row+step.Row, position.Col+step.Col; isValidArea(row, col); row, col = row+step.Row, col+step.Col {
+				rays[direction][square] |= squareBit(squareIndex(Position{row, col}))
+			}
+		}
+
+		// white pawns move to the lower rows
+		for _, col := range []int{position.Col - 1, position.Col + 1} {
+			if isValidArea(position.Row-1, col) {
+				pawnAttacks[0][square] |= squareBit(squareIndex(Position{position.Row - 1, col}))
+			}
+			if isValidArea(position.Row+1, col) {
+				pawnAttacks[1][square] |= squareBit(squareIndex(Position{position.Row + 1, col}))
+			}
+		}
+	}
+}
+
+// rayAttacks returns the squares in the direction up to and including the first occupied one
+func rayAttacks(square int, occupied bitboard, direction int) bitboard {
+	attacks := rays[direction][square]
+
+	if blockers := attacks & occupied; blockers != 0 {
+		blocker := blockers.msb()
+		if direction < north {
+			blocker = blockers.lsb()
+		}
+
+		attacks &^= rays[direction][blocker]
+	}
+
+	return attacks
+}
+
+func rookAttacks(square int, occupied bitboard) bitboard {
+	return rayAttacks(square, occupied, north) | rayAttacks(square, occupied, south) |
+		rayAttacks(square, occupied, east) | rayAttacks(square, occupied, west)
+}
+
+func bishopAttacks(square int, occupied bitboard) bitboard {
+	return rayAttacks(square, occupied, northEast) | rayAttacks(square, occupied, northWest) |
+		rayAttacks(square, occupied, southEast) | rayAttacks(square, occupied, southWest)
+}
+
+// syncBitboards rebuilds the bitboards from the Pieces array
+func (c *Chessboard) syncBitboards() {
+	c.bitboards = bitboards{}
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			if piece := c.Pieces[i][j]; piece != nil {
+				c.bitboards.set(piece, squareIndex(Position{i, j}))
+			}
+		}
+	}
+}
+
+// setPiece puts the piece on the position, replacing the piece standing there
+func (c *Chessboard) setPiece(position Position, piece *Piece) {
+	c.removePiece(position)
+
+	c.Pieces[position.Row][position.Col] = piece
+	piece.Position = position
+	c.bitboards.set(piece, squareIndex(position))
+	c.hash ^= zobristPiece(piece, squareIndex(position))
+}
+
+// removePiece takes the piece on the position off the board
+func (c *Chessboard) removePiece(position Position) {
+	if piece := c.Pieces[position.Row][position.Col]; piece != nil {
+		c.bitboards.clear(piece, squareIndex(position))
+		c.hash ^= zobristPiece(piece, squareIndex(position))
+		c.Pieces[position.Row][position.Col] = nil
+	}
+}
@@ -1,6 +1,9 @@
 package chessboard
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type ChessboardPiece struct {
 	Color     Color
@@ -8,46 +11,323 @@ type ChessboardPiece struct {
 	Row, Col  int
 }
 
+type Castling string
+
+const (
+	KingsideCastling  Castling = "O-O"
+	QueensideCastling Castling = "O-O-O"
+)
+
 type ChessBoardMove struct {
 	From Position
 	To   Position
+
+	// EnPassant is the position of the pawn captured en passant, nil for any other move
+	EnPassant *Position
+
+	// Promotion is the piece a pawn is promoted to on the last rank, empty for any other move
+	Promotion PieceType
+
+	// Castling is the side the king castled to, empty for any other move
+	Castling Castling
+
+	// SAN is the standard algebraic notation of the move, like Nxe5+
+	SAN string
+}
+
+// CastlingRook returns the squares the rook moves between when the move is castling
+func (m *ChessBoardMove) CastlingRook() (from, to Position) {
+	if m.Castling == QueensideCastling {
+		return Position{m.To.Row, 0}, Position{m.To.Row, 3}
+	}
+	return Position{m.To.Row, 7}, Position{m.To.Row, 5}
+}
+
+// UCI returns the move in the long algebraic notation of the UCI protocol, like e7e8q
+func (m *ChessBoardMove) UCI() string {
+	return m.From.String() + m.To.String() + strings.ToLower(string(m.Promotion))
+}
+
+// ParseUCI parses a move in the long algebraic notation of the UCI protocol, the
+// inverse of UCI. The move is not checked against any position.
+func ParseUCI(move string) (*ChessBoardMove, error) {
+	if len(move) != 4 && len(move) != 5 {
+		return nil, fmt.Errorf("invalid uci move %q", move)
+	}
+
+	from, err := GetPosition(move[:2])
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := GetPosition(move[2:4])
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := ParsePromotion(move[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChessBoardMove{From: *from, To: *to, Promotion: promotion}, nil
 }
+
 type Chessboard struct {
+	// Pieces is mirrored by the bitboards, it is only changed through setPiece and removePiece
 	Pieces     [8][8]*Piece
 	MovesCount [8][8]int
+
+	// EnPassant is the square skipped by the last double pawn push, the only
+	// square an en passant capture can land on. nil when no capture is possible.
+	EnPassant *Position
+
+	// Turn is the color to move
+	Turn Color
+
+	// HalfmoveClock is the number of halfmoves since the last capture or pawn move
+	HalfmoveClock int
+
+	// FullmoveNumber starts at 1 and is increased after every black move
+	FullmoveNumber int
+
+	// history holds the hash of every position reached in the game, the current one included
+	history []uint64
+
+	// hash is the zobrist hash of the position, kept up to date by every move
+	hash uint64
+
+	// undo holds what every played move changed so it can be taken back, the last move last
+	undo []undoState
+
+	bitboards bitboards
+}
+
+// undoState is everything a move changes that can not be worked out from the move itself
+type undoState struct {
+	move     *ChessBoardMove
+	piece    *Piece
+	captured *Piece
+
+	enPassant      *Position
+	turn           Color
+	halfmoveClock  int
+	fullmoveNumber int
+	hash           uint64
 }
 
 func NewDefault() *Chessboard {
-	board := new(Chessboard)
+	board := &Chessboard{Turn: White, FullmoveNumber: 1}
 	board.setupDefult()
+	board.resetHistory()
 	return board
 }
 
+// New rebuilds a board from its pieces and the moves played so far. When the
+// moves replay from the default position to the same pieces the board gets
+// its full history back, otherwise it starts tracking from the given pieces.
 func New(pieces []*ChessboardPiece, moves []*ChessBoardMove) *Chessboard {
-	board := &Chessboard{}
-
 	if len(pieces) == 0 {
-		board.setupDefult()
+		return NewDefault()
+	}
+
+	if board, ok := replay(pieces, moves); ok {
 		return board
 	}
 
+	board := &Chessboard{Turn: White, FullmoveNumber: 1 + len(moves)/2}
+
 	for _, piece := range pieces {
 		board.Pieces[piece.Row][piece.Col] = NewPiece(piece.PieceType, piece.Color, piece.Row, piece.Col)
 	}
 
+	board.syncBitboards()
+
 	for _, move := range moves {
+		if move == nil {
+			continue
+		}
+
 		board.increaseMovesCount(move.From, move.To)
+
+		if move.Castling != "" {
+			board.increaseMovesCount(move.CastlingRook())
+		}
+	}
+
+	// only the last move can leave an en passant capture behind
+	if len(moves) > 0 && moves[len(moves)-1] != nil {
+		last := moves[len(moves)-1]
+		lastPiece := board.GetPiece(last.To.Row, last.To.Col)
+
+		board.setEnPassant(lastPiece, last.From, last.To)
+
+		if lastPiece != nil {
+			board.Turn = getOpponentColor(lastPiece.Color)
+		}
 	}
 
+	board.resetHistory()
+
 	return board
 }
 
+// NewFromMoves plays the moves from the default position
+func NewFromMoves(moves []*ChessBoardMove) (*Chessboard, error) {
+	return playMoves(NewDefault(), moves)
+}
+
+// NewFromFENAndMoves plays the moves from the position of the FEN
+func NewFromFENAndMoves(fen string, moves []*ChessBoardMove) (*Chessboard, error) {
+	board, err := NewFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	return playMoves(board, moves)
+}
+
+func playMoves(board *Chessboard, moves []*ChessBoardMove) (*Chessboard, error) {
+	for i, move := range moves {
+		if move == nil {
+			return nil, fmt.Errorf("move %d is missing", i+1)
+		}
+
+		if _, err := board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	return board, nil
+}
+
+// replay plays the moves from the default position and reports whether it
+// ends up with the given pieces
+func replay(pieces []*ChessboardPiece, moves []*ChessBoardMove) (*Chessboard, bool) {
+	board, err := NewFromMoves(moves)
+	if err != nil {
+		return nil, false
+	}
+
+	replayed := board.GetPieces()
+	if len(replayed) != len(pieces) {
+		return nil, false
+	}
+
+	for _, piece := range pieces {
+		p := board.GetPiece(piece.Row, piece.Col)
+		if p == nil || p.Type != piece.PieceType || p.Color != piece.Color {
+			return nil, false
+		}
+	}
+
+	return board, true
+}
+
 func (c *Chessboard) increaseMovesCount(from, to Position) {
 	c.MovesCount[from.Row][from.Col]++
 	c.MovesCount[to.Row][to.Col]++
 }
 
-func (c *Chessboard) PlacePiece(piece *Piece, position Position) error {
+func (c *Chessboard) decreaseMovesCount(from, to Position) {
+	c.MovesCount[from.Row][from.Col]--
+	c.MovesCount[to.Row][to.Col]--
+}
+
+// PlacePiece moves the piece to the position, promotion is the piece a pawn
+// reaching the last rank turns into and must be empty for any other move.
+func (c *Chessboard) PlacePiece(piece *Piece, position Position, promotion PieceType) (*ChessBoardMove, error) {
+	if err := c.validateMove(piece, position, promotion); err != nil {
+		return nil, err
+	}
+
+	// the notation depends on the position before the move
+	san, err := c.SAN(piece.Position, position, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	move := c.movePiece(piece, position, promotion)
+	move.SAN = san
+
+	return move, nil
+}
+
+// MakeMove plays the move like PlacePiece without working out its notation,
+// every move played on the board can be taken back with UnmakeMove
+func (c *Chessboard) MakeMove(move *ChessBoardMove) (*ChessBoardMove, error) {
+	piece := c.GetPiece(move.From.Row, move.From.Col)
+
+	if piece == nil {
+		return nil, fmt.Errorf("there is no piece in %s", move.From)
+	}
+
+	if err := c.validateMove(piece, move.To, move.Promotion); err != nil {
+		return nil, err
+	}
+
+	return c.movePiece(piece, move.To, move.Promotion), nil
+}
+
+// UnmakeMove takes back the last move and restores the captured piece, the
+// castling rights, the en passant square and the clocks from before the move
+func (c *Chessboard) UnmakeMove() (*ChessBoardMove, error) {
+	if len(c.undo) == 0 {
+		return nil, ErrNoMoveToUnmake
+	}
+
+	state := c.undo[len(c.undo)-1]
+	c.undo = c.undo[:len(c.undo)-1]
+
+	move, piece := state.move, state.piece
+
+	c.removePiece(move.To)
+
+	if move.Promotion != "" {
+		piece.Type = Pawn
+	}
+
+	c.setPiece(move.From, piece)
+	c.decreaseMovesCount(move.From, move.To)
+
+	if move.Castling != "" {
+		rookFrom, rookTo := move.CastlingRook()
+		rook := c.GetPiece(rookTo.Row, rookTo.Col)
+
+		c.removePiece(rookTo)
+		c.setPiece(rookFrom, rook)
+		c.decreaseMovesCount(rookFrom, rookTo)
+	}
+
+	if state.captured != nil {
+		captured := move.To
+		if move.EnPassant != nil {
+			captured = *move.EnPassant
+		}
+
+		c.setPiece(captured, state.captured)
+	}
+
+	c.EnPassant = state.enPassant
+	c.Turn = state.turn
+	c.HalfmoveClock = state.halfmoveClock
+	c.FullmoveNumber = state.fullmoveNumber
+	c.hash = state.hash
+
+	if len(c.history) > 1 {
+		c.history = c.history[:len(c.history)-1]
+	}
+
+	return move, nil
+}
+
+// CanUnmakeMove checks if there is a move played on the board to take back
+func (c *Chessboard) CanUnmakeMove() bool {
+	return len(c.undo) > 0
+}
+
+// validateMove checks if moving the piece to the position is legal with the promotion
+func (c *Chessboard) validateMove(piece *Piece, position Position, promotion PieceType) error {
 	isValidMove := false
 	validMoves := c.GetValidMoves(piece)
 
@@ -59,26 +339,137 @@ func (c *Chessboard) PlacePiece(piece *Piece, position Position) error {
 	}
 
 	if !isValidMove {
-		return fmt.Errorf("this is not a valid move from [%d %d] to [%d %d]", piece.Position.Row, position.Col, position.Row, position.Col)
+		return fmt.Errorf("this is not a valid move from %s to %s", piece.Position, position)
 	}
 
-	c.increaseMovesCount(piece.Position, position)
+	if isPromotionMove(piece, position) {
+		if promotion == "" {
+			return ErrPromotionRequired
+		}
 
-	c.Pieces[piece.Position.Row][piece.Position.Col] = nil
-	c.Pieces[position.Row][position.Col] = piece
-	piece.Position = position
+		if !isValidPromotion(promotion) {
+			return ErrInvalidPromotion
+		}
+	} else if promotion != "" {
+		return ErrInvalidPromotion
+	}
 
 	return nil
 }
 
-func (c *Chessboard) PlacePieceFromPosition(from, to Position) error {
+// movePiece moves the piece without any validation and applies the side
+// effects of the move, like taking the pawn captured en passant.
+func (c *Chessboard) movePiece(piece *Piece, position Position, promotion PieceType) *ChessBoardMove {
+	move := &ChessBoardMove{
+		From:      piece.Position,
+		To:        position,
+		Promotion: promotion,
+	}
+
+	state := undoState{
+		move:           move,
+		piece:          piece,
+		captured:       c.GetPiece(position.Row, position.Col),
+		enPassant:      c.EnPassant,
+		turn:           c.Turn,
+		halfmoveClock:  c.HalfmoveClock,
+		fullmoveNumber: c.FullmoveNumber,
+		hash:           c.hash,
+	}
+
+	// the pieces update the hash as they are moved, the rest is replaced after the move
+	c.hash ^= c.stateHash()
+
+	// a capture or a pawn move resets the halfmove clock
+	if piece.Type == Pawn || !c.isEmptyPiece(position.Row, position.Col) {
+		c.HalfmoveClock = 0
+	} else {
+		c.HalfmoveClock++
+	}
+
+	if c.isEnPassantCapture(piece, position) {
+		captured := Position{piece.Position.Row, position.Col}
+		state.captured = c.GetPiece(captured.Row, captured.Col)
+		c.removePiece(captured)
+		move.EnPassant = &captured
+	}
+
+	// castling is the only move where the king steps two squares, the rook goes with it
+	if piece.Type == King && abs(position.Col-piece.Position.Col) == 2 {
+		move.Castling = KingsideCastling
+		if position.Col < piece.Position.Col {
+			move.Castling = QueensideCastling
+		}
+
+		rookFrom, rookTo := move.CastlingRook()
+		rook := c.Pieces[rookFrom.Row][rookFrom.Col]
+
+		c.increaseMovesCount(rookFrom, rookTo)
+		c.removePiece(rookFrom)
+		c.setPiece(rookTo, rook)
+	}
+
+	c.increaseMovesCount(piece.Position, position)
+	c.removePiece(piece.Position)
+
+	if promotion != "" {
+		piece.Type = promotion
+	}
+
+	c.setPiece(position, piece)
+
+	c.setEnPassant(piece, move.From, move.To)
+	c.Turn = getOpponentColor(piece.Color)
+
+	if piece.Color == Black {
+		c.FullmoveNumber++
+	}
+
+	c.hash ^= c.stateHash()
+
+	// simulated boards do not keep a history
+	if c.history != nil {
+		c.history = append(c.history, c.hash)
+	}
+
+	c.undo = append(c.undo, state)
+
+	return move
+}
+
+// isEnPassantCapture checks if moving the piece to the position takes a pawn en passant
+func (c *Chessboard) isEnPassantCapture(piece *Piece, to Position) bool {
+	if piece.Type != Pawn || c.EnPassant == nil || *c.EnPassant != to ||
+		piece.Position.Col == to.Col || !c.isEmptyPiece(to.Row, to.Col) {
+		return false
+	}
+
+	captured := c.GetPiece(piece.Position.Row, to.Col)
+	return captured != nil && captured.Type == Pawn && captured.Color != piece.Color
+}
+
+// setEnPassant sets the en passant target square after a move from -> to
+func (c *Chessboard) setEnPassant(piece *Piece, from, to Position) {
+	c.EnPassant = nil
+
+	if piece != nil && piece.Type == Pawn && abs(from.Row-to.Row) == 2 {
+		c.EnPassant = &Position{(from.Row + to.Row) / 2, from.Col}
+	}
+}
+
+// isPromotionMove checks if the piece is a pawn reaching the last rank
+func isPromotionMove(piece *Piece, to Position) bool {
+	return piece.Type == Pawn && ((piece.Color == White && to.Row == 0) || (piece.Color == Black && to.Row == 7))
+}
+
+func (c *Chessboard) PlacePieceFromPosition(from, to Position, promotion PieceType) (*ChessBoardMove, error) {
 	piece := c.GetPiece(from.Row, from.Col)
 
 	if piece == nil {
-		return fmt.Errorf("there is no piece in [%d %d]", from.Row, from.Col)
+		return nil, fmt.Errorf("there is no piece in [%d %d]", from.Row, from.Col)
 	}
 
-	return c.PlacePiece(piece, to)
+	return c.PlacePiece(piece, to, promotion)
 }
 
 func (c *Chessboard) GetPiece(row, col int) *Piece {
@@ -123,6 +514,7 @@ func (c *Chessboard) setupDefult() {
 		c.Pieces[6][i] = NewPiece(Pawn, White, 6, i)
 	}
 
+	c.syncBitboards()
 }
 
 func (c *Chessboard) GetPieces() []*ChessboardPiece {
@@ -0,0 +1,193 @@
+package chessboard
+
+import "strings"
+
+const (
+	// halfmoves without a capture or a pawn move
+	fiftyMoveRuleHalfmoves       = 100
+	seventyFiveMoveRuleHalfmoves = 150
+	threefoldRepetitionCount     = 3
+	fivefoldRepetitionCount      = 5
+)
+
+// RepetitionCount returns how many times the current position has occurred in the game
+func (c *Chessboard) RepetitionCount() int {
+	if len(c.history) == 0 {
+		return 1
+	}
+
+	var (
+		key   = c.history[len(c.history)-1]
+		count = 0
+	)
+
+	for _, position := range c.history {
+		if position == key {
+			count++
+		}
+	}
+
+	return count
+}
+
+// HasInsufficientMaterial checks if neither side can possibly checkmate: K vs K,
+// K+B vs K, K+N vs K and kings with bishops that are all on the same square color.
+func (c *Chessboard) HasInsufficientMaterial() bool {
+	var (
+		knights      = 0
+		bishops      = 0
+		bishopColors = make(map[int]bool)
+	)
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			piece := c.Pieces[i][j]
+			if piece == nil {
+				continue
+			}
+
+			switch piece.Type {
+			case King:
+			case Knight:
+				knights++
+			case Bishop:
+				bishops++
+				bishopColors[(i+j)%2] = true
+			default:
+				// a pawn, rook or queen can always mate
+				return false
+			}
+		}
+	}
+
+	if knights == 0 && bishops == 0 {
+		return true
+	}
+
+	if knights+bishops == 1 {
+		return true
+	}
+
+	// bishops only, all of them on the same square color
+	return knights == 0 && len(bishopColors) == 1
+}
+
+// HasMatingMaterial checks if the color has more than a lone king or a king
+// with a single knight or bishop, the material it takes to win on time
+func (c *Chessboard) HasMatingMaterial(color Color) bool {
+	minorPieces := 0
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			piece := c.Pieces[i][j]
+			if piece == nil || piece.Color != color {
+				continue
+			}
+
+			switch piece.Type {
+			case King:
+			case Knight, Bishop:
+				minorPieces++
+			default:
+				return true
+			}
+		}
+	}
+
+	return minorPieces >= 2
+}
+
+// evaluateAutomaticDraw returns the draw that ends the game without any claim
+func (c *Chessboard) evaluateAutomaticDraw() *GameResult {
+	switch {
+	case c.RepetitionCount() >= fivefoldRepetitionCount:
+		return &GameResult{Termination: TerminationFivefoldRepetition}
+
+	case c.HalfmoveClock >= seventyFiveMoveRuleHalfmoves:
+		return &GameResult{Termination: TerminationSeventyFiveMoveRule}
+
+	case c.HasInsufficientMaterial():
+		return &GameResult{Termination: TerminationInsufficientMaterial}
+	}
+
+	return nil
+}
+
+// ClaimDraw returns the draw the side to move can claim, threefold repetition or the fifty-move rule
+func (c *Chessboard) ClaimDraw() (*GameResult, error) {
+	switch {
+	case c.RepetitionCount() >= threefoldRepetitionCount:
+		return &GameResult{Termination: TerminationThreefoldRepetition}, nil
+
+	case c.HalfmoveClock >= fiftyMoveRuleHalfmoves:
+		return &GameResult{Termination: TerminationFiftyMoveRule}, nil
+	}
+
+	return nil, ErrDrawNotClaimable
+}
+
+// canCaptureEnPassant checks if a pawn of the side to move can take en passant
+func (c *Chessboard) canCaptureEnPassant() bool {
+	direction := 1
+	if c.Turn == White {
+		direction = -1
+	}
+
+	for _, col := range []int{c.EnPassant.Col - 1, c.EnPassant.Col + 1} {
+		piece := c.GetPiece(c.EnPassant.Row-direction, col)
+		if piece != nil && piece.Color == c.Turn && c.isEnPassantCapture(piece, *c.EnPassant) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// castlingRights returns the castling rights that are not lost yet in the
+// KQkq form, it does not care whether castling is possible right now.
+func (c *Chessboard) castlingRights() string {
+	var (
+		mask   = c.castlingRightsMask()
+		rights = ""
+	)
+
+	for i, right := range []string{"K", "Q", "k", "q"} {
+		if mask&(1<<i) != 0 {
+			rights += right
+		}
+	}
+
+	if rights == "" {
+		return "-"
+	}
+
+	return rights
+}
+
+// castlingRightsMask returns the castling rights that are not lost yet as a bit mask
+func (c *Chessboard) castlingRightsMask() int {
+	mask := 0
+
+	if c.isCastlingPieces(White, 7) && !c.hasPieceMoved(Position{7, 4}) && !c.hasPieceMoved(Position{7, 7}) {
+		mask |= whiteKingsideCastling
+	}
+	if c.isCastlingPieces(White, 0) && !c.hasPieceMoved(Position{7, 4}) && !c.hasPieceMoved(Position{7, 0}) {
+		mask |= whiteQueensideCastling
+	}
+	if c.isCastlingPieces(Black, 7) && !c.hasPieceMoved(Position{0, 4}) && !c.hasPieceMoved(Position{0, 7}) {
+		mask |= blackKingsideCastling
+	}
+	if c.isCastlingPieces(Black, 0) && !c.hasPieceMoved(Position{0, 4}) && !c.hasPieceMoved(Position{0, 0}) {
+		mask |= blackQueensideCastling
+	}
+
+	return mask
+}
+
+// pieceSymbol returns the piece letter, upper case for white and lower case for black
+func pieceSymbol(piece *Piece) string {
+	if piece.Color == White {
+		return string(piece.Type)
+	}
+	return strings.ToLower(string(piece.Type))
+}
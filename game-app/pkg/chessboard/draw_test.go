@@ -0,0 +1,32 @@
+package chessboard
+
+import "testing"
+
+func TestHasMatingMaterial(t *testing.T) {
+	tests := []struct {
+		fen          string
+		white, black bool
+	}{
+		{DefaultFEN, true, true},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", false, false},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false, false},
+		{"4k3/8/8/8/8/8/8/1NB1K3 w - - 0 1", true, false},
+		{"4k3/p7/8/8/8/8/8/4K3 w - - 0 1", false, true},
+		{"4kr2/8/8/8/8/8/8/4K1N1 w - - 0 1", false, true},
+	}
+
+	for _, test := range tests {
+		board, err := NewFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fen, err)
+		}
+
+		if got := board.HasMatingMaterial(White); got != test.white {
+			t.Errorf("%s: expected %v for white, got %v", test.fen, test.white, got)
+		}
+
+		if got := board.HasMatingMaterial(Black); got != test.black {
+			t.Errorf("%s: expected %v for black, got %v", test.fen, test.black, got)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package chessboard
+
+import "errors"
+
+var (
+	ErrPromotionRequired = errors.New("a pawn reaching the last rank must be promoted")
+	ErrInvalidPromotion  = errors.New("invalid promotion, a pawn can only be promoted to Q, R, B or N on the last rank")
+	ErrInvalidFEN        = errors.New("invalid FEN")
+	ErrDrawNotClaimable  = errors.New("there is no threefold repetition or fifty-move rule to claim a draw")
+	ErrNoMoveToUnmake    = errors.New("there is no move to take back")
+	ErrInvalidKings      = errors.New("a position must have exactly one king of each color")
+	ErrOpponentInCheck   = errors.New("the side not to move can not be in check")
+	ErrPawnOnBackRank    = errors.New("a pawn can not stand on the first or the last rank")
+)
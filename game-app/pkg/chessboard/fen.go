@@ -0,0 +1,174 @@
+package chessboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const DefaultFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// NewFromFEN builds a board from a FEN string, the halfmove and fullmove
+// counters are optional and default to 0 and 1
+func NewFromFEN(fen string) (*Chessboard, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return nil, ErrInvalidFEN
+	}
+
+	board := &Chessboard{FullmoveNumber: 1}
+
+	// piece placement, from the 8th rank down to the 1st
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, ErrInvalidFEN
+	}
+
+	for row, rank := range ranks {
+		col := 0
+		for _, ch := range rank {
+			if ch >= '1' && ch <= '8' {
+				col += int(ch - '0')
+				continue
+			}
+
+			pieceType, color, ok := parsePieceSymbol(ch)
+			if !ok || col > 7 {
+				return nil, ErrInvalidFEN
+			}
+
+			board.setPiece(Position{row, col}, NewPiece(pieceType, color, row, col))
+			col++
+		}
+
+		if col != 8 {
+			return nil, ErrInvalidFEN
+		}
+	}
+
+	// side to move
+	switch fields[1] {
+	case "w":
+		board.Turn = White
+	case "b":
+		board.Turn = Black
+	default:
+		return nil, ErrInvalidFEN
+	}
+
+	// castling rights
+	if err := board.setCastlingRights(fields[2]); err != nil {
+		return nil, err
+	}
+
+	// en passant target square
+	if fields[3] != "-" {
+		enPassant, err := GetPosition(fields[3])
+		if err != nil || (enPassant.Row != 2 && enPassant.Row != 5) {
+			return nil, ErrInvalidFEN
+		}
+
+		board.EnPassant = enPassant
+	}
+
+	// halfmove clock and fullmove number
+	if len(fields) == 6 {
+		halfmoveClock, err := strconv.Atoi(fields[4])
+		if err != nil || halfmoveClock < 0 {
+			return nil, ErrInvalidFEN
+		}
+
+		fullmoveNumber, err := strconv.Atoi(fields[5])
+		if err != nil || fullmoveNumber < 1 {
+			return nil, ErrInvalidFEN
+		}
+
+		board.HalfmoveClock = halfmoveClock
+		board.FullmoveNumber = fullmoveNumber
+	}
+
+	board.resetHistory()
+
+	return board, nil
+}
+
+// FEN serialises the board to a FEN string
+func (c *Chessboard) FEN() string {
+	var sb strings.Builder
+
+	for i := 0; i < 8; i++ {
+		empty := 0
+
+		for j := 0; j < 8; j++ {
+			piece := c.Pieces[i][j]
+			if piece == nil {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+
+			sb.WriteString(pieceSymbol(piece))
+		}
+
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+
+		if i < 7 {
+			sb.WriteByte('/')
+		}
+	}
+
+	turn := "w"
+	if c.Turn == Black {
+		turn = "b"
+	}
+
+	enPassant := "-"
+	if c.EnPassant != nil {
+		enPassant = c.EnPassant.String()
+	}
+
+	return fmt.Sprintf("%s %s %s %s %d %d", sb.String(), turn, c.castlingRights(), enPassant, c.HalfmoveClock, c.FullmoveNumber)
+}
+
+// setCastlingRights marks the king or rook of every lost castling right as
+// moved, which is how the board keeps track of castling
+func (c *Chessboard) setCastlingRights(rights string) error {
+	if rights != "-" && strings.Trim(rights, "KQkq") != "" {
+		return ErrInvalidFEN
+	}
+
+	lost := func(right string, rookSquare Position) {
+		if !strings.Contains(rights, right) {
+			c.MovesCount[rookSquare.Row][rookSquare.Col]++
+		}
+	}
+
+	lost("K", Position{7, 7})
+	lost("Q", Position{7, 0})
+	lost("k", Position{0, 7})
+	lost("q", Position{0, 0})
+
+	return nil
+}
+
+func parsePieceSymbol(symbol rune) (PieceType, Color, bool) {
+	color := White
+	if symbol >= 'a' && symbol <= 'z' {
+		color = Black
+	}
+
+	pieceType := PieceType(strings.ToUpper(string(symbol)))
+
+	switch pieceType {
+	case Pawn, Rook, Knight, Bishop, Queen, King:
+		return pieceType, color, true
+	default:
+		return "", "", false
+	}
+}
@@ -33,13 +33,14 @@ func max(a, b int) int {
 	return b
 }
 
+// GetPosition parses an algebraic square name like e4, the inverse of Position.String
 func GetPosition(position string) (*Position, error) {
 	if len(position) != 2 {
 		return nil, errors.New("invalid position format")
 	}
 
-	row, err := strconv.Atoi(string(position[1]))
-	if err != nil || row < 1 || row > 8 {
+	rank, err := strconv.Atoi(string(position[1]))
+	if err != nil || rank < 1 || rank > 8 {
 		return nil, errors.New("invalid row value")
 	}
 
@@ -49,7 +50,7 @@ func GetPosition(position string) (*Position, error) {
 	}
 
 	return &Position{
-		Row: row - 1,
+		Row: 8 - rank,
 		Col: col,
 	}, nil
 }
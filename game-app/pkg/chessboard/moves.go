@@ -1,22 +1,30 @@
 package chessboard
 
-// GetValidMoves returns valid moves for a piece at the specified position
+// GetValidMoves returns the legal moves for a piece at the specified position,
+// a move is dropped when it leaves the own king attacked. That covers pinned
+// pieces and the king stepping into an attacked square, not only escaping a check.
 func (c *Chessboard) GetValidMoves(piece *Piece) []Position {
-	moves := c.calculateValidMoves(piece)
+	if c.GetPiece(piece.Position.Row, piece.Position.Col) != piece {
+		return []Position{}
+	}
 
-	if c.IsInCheck(piece.Color) {
-		var newValidMoves []Position
+	targets := c.getPseudoLegalTargets(piece)
+	validMoves := make([]Position, 0, targets.count())
 
-		for i := 0; i < len(moves); i++ {
-			if !c.wouldMoveResultInCheck(piece.Color, piece.Position, moves[i]) {
-				newValidMoves = append(newValidMoves, moves[i])
-			}
+	for targets != 0 {
+		to := squarePosition(targets.popLSB())
+
+		if !c.wouldMoveResultInCheck(piece.Color, piece.Position, to) {
+			validMoves = append(validMoves, to)
 		}
+	}
 
-		return newValidMoves
-	} else {
-		return moves
+	// castling already makes sure the king does not pass an attacked square
+	if piece.Type == King {
+		validMoves = append(validMoves, c.getValidCastlingMoves(piece.Color, piece.Position)...)
 	}
+
+	return validMoves
 }
 
 func (c *Chessboard) GetValidMovesFromPosition(position Position) []Position {
@@ -29,236 +37,134 @@ func (c *Chessboard) GetValidMovesFromPosition(position Position) []Position {
 	return c.GetValidMoves(piece)
 }
 
-func (c *Chessboard) calculateValidMoves(piece *Piece) []Position {
+// getPseudoLegalTargets returns the squares the piece can move to without
+// looking at the safety of the own king, castling is not included.
+func (c *Chessboard) getPseudoLegalTargets(piece *Piece) bitboard {
+	var (
+		square   = squareIndex(piece.Position)
+		own      = c.bitboards.occupied[colorIndex(piece.Color)]
+		occupied = c.bitboards.all()
+	)
+
 	switch piece.Type {
 	case Pawn:
-		return c.getValidMovesForPawn(piece)
-
-	case Rook:
-		return c.getValidMovesForRook(piece)
+		return c.getPawnTargets(piece)
 
 	case Knight:
-		return c.getValidMovesForKnight(piece)
+		return knightAttacks[square] &^ own
 
 	case Bishop:
-		return c.getValidMovesForBishop(piece)
+		return bishopAttacks(square, occupied) &^ own
+
+	case Rook:
+		return rookAttacks(square, occupied) &^ own
 
 	case Queen:
-		return c.getValidMovesForQueen(piece)
+		return (bishopAttacks(square, occupied) | rookAttacks(square, occupied)) &^ own
 
 	case King:
-		return c.getValidMovesForKing(piece)
+		return kingAttacks[square] &^ own
 
 	default:
-		return nil
+		return 0
 	}
 }
 
-func (c *Chessboard) getValidMovesForPawn(piece *Piece) []Position {
+func (c *Chessboard) getPawnTargets(piece *Piece) bitboard {
 	var (
-		position   = piece.Position
-		validMoves = make([]Position, 0)
+		position = piece.Position
+		color    = colorIndex(piece.Color)
+		occupied = c.bitboards.all()
+		targets  bitboard
 	)
 
 	// Determine the direction of movement based on the pawn's color
-	direction := 1
+	direction, startRow := 1, 1
 	if piece.Color == White {
-		direction = -1
-	}
-
-	// Move one square forward
-	newPosition := Position{position.Row + direction, position.Col}
-	if c.IsValidMove(piece, newPosition) && c.isEmptyPiece(newPosition.Row, newPosition.Col) {
-		validMoves = append(validMoves, newPosition)
-	}
-
-	// Move two squares forward on the first move
-	if (piece.Color == White && position.Row == 6) || (piece.Color == Black && position.Row == 1) {
-		newPosition := Position{position.Row + 2*direction, position.Col}
-		if c.IsValidMove(piece, newPosition) && c.isEmptyPiece(newPosition.Row, newPosition.Col) {
-			validMoves = append(validMoves, newPosition)
-		}
-	}
-
-	// Capture diagonally
-	capturePositions := []Position{{position.Row + direction, position.Col - 1}, {position.Row + direction, position.Col + 1}}
-	for _, capturePos := range capturePositions {
-		if c.IsValidMove(piece, capturePos) && !c.isEmptyPiece(capturePos.Row, capturePos.Col) &&
-			c.GetPiece(capturePos.Row, capturePos.Col).Color != piece.Color {
-			validMoves = append(validMoves, capturePos)
-		}
+		direction, startRow = -1, 6
 	}
 
-	return validMoves
-}
-
-func (c *Chessboard) getValidMovesForRook(piece *Piece) []Position {
-	var (
-		position   = piece.Position
-		validMoves = make([]Position, 0)
-	)
-
-	// Check horizontally
-	for i := 0; i < 8; i++ {
-		newPosition := Position{position.Row, i}
-		if c.IsValidMove(piece, newPosition) {
-			validMoves = append(validMoves, newPosition)
-		}
-	}
+	// Move one square forward, and two squares on the first move
+	if isValidArea(position.Row+direction, position.Col) {
+		forward := squareIndex(Position{position.Row + direction, position.Col})
 
-	// Check vertically
-	for i := 0; i < 8; i++ {
-		newPosition := Position{i, position.Col}
-		if c.IsValidMove(piece, newPosition) {
-			validMoves = append(validMoves, newPosition)
-		}
-	}
+		if !occupied.has(forward) {
+			targets |= squareBit(forward)
 
-	return validMoves
-}
-
-func (c *Chessboard) getValidMovesForKnight(piece *Piece) []Position {
-	var (
-		position   = piece.Position
-		validMoves = make([]Position, 0)
-	)
-
-	possibleMoves := []Position{
-		{position.Row - 2, position.Col - 1}, {position.Row - 2, position.Col + 1},
-		{position.Row - 1, position.Col - 2}, {position.Row - 1, position.Col + 2},
-		{position.Row + 1, position.Col - 2}, {position.Row + 1, position.Col + 2},
-		{position.Row + 2, position.Col - 1}, {position.Row + 2, position.Col + 1},
-	}
-
-	for _, move := range possibleMoves {
-		if c.IsValidMove(piece, move) {
-			validMoves = append(validMoves, move)
-		}
-	}
-
-	return validMoves
-}
-
-func (c *Chessboard) getValidMovesForBishop(piece *Piece) []Position {
-	var (
-		position   = piece.Position
-		validMoves = make([]Position, 0)
-	)
-
-	// Check diagonally
-	for i := 0; i < 8; i++ {
-		newPosition1 := Position{position.Row + i, position.Col + i}
-		newPosition2 := Position{position.Row + i, position.Col - i}
-		newPosition3 := Position{position.Row - i, position.Col + i}
-		newPosition4 := Position{position.Row - i, position.Col - i}
-
-		if c.IsValidMove(piece, newPosition1) {
-			validMoves = append(validMoves, newPosition1)
-		}
-		if c.IsValidMove(piece, newPosition2) {
-			validMoves = append(validMoves, newPosition2)
-		}
-		if c.IsValidMove(piece, newPosition3) {
-			validMoves = append(validMoves, newPosition3)
-		}
-		if c.IsValidMove(piece, newPosition4) {
-			validMoves = append(validMoves, newPosition4)
+			doubleForward := squareIndex(Position{position.Row + 2*direction, position.Col})
+			if position.Row == startRow && !occupied.has(doubleForward) {
+				targets |= squareBit(doubleForward)
+			}
 		}
 	}
 
-	return validMoves
-}
-
-func (c *Chessboard) getValidMovesForQueen(piece *Piece) []Position {
-	rookMoves := c.getValidMovesForRook(piece)
-	bishopMoves := c.getValidMovesForBishop(piece)
-	return append(rookMoves, bishopMoves...)
-}
-
-func (c *Chessboard) getValidMovesForKing(piece *Piece) []Position {
-	var (
-		position   = piece.Position
-		validMoves = make([]Position, 0)
-	)
-
-	// Check in all eight directions
-	directions := []Position{
-		{1, 0}, {1, 1}, {0, 1}, {-1, 1},
-		{-1, 0}, {-1, -1}, {0, -1}, {1, -1},
-	}
+	// Capture diagonally
+	attacks := pawnAttacks[color][squareIndex(position)]
+	targets |= attacks & c.bitboards.occupied[1-color]
 
-	for _, dir := range directions {
-		newPosition := Position{position.Row + dir.Row, position.Col + dir.Col}
-		if c.IsValidMove(piece, newPosition) {
-			validMoves = append(validMoves, newPosition)
-		}
+	// Capture en passant
+	if c.EnPassant != nil && attacks.has(squareIndex(*c.EnPassant)) && c.isEnPassantCapture(piece, *c.EnPassant) {
+		targets |= squareBit(squareIndex(*c.EnPassant))
 	}
 
-	// Check for castling moves
-	castlingMoves := c.getValidCastlingMoves(piece.Color, position)
-	validMoves = append(validMoves, castlingMoves...)
-
-	return validMoves
+	return targets
 }
 
 // getValidCastlingMoves returns valid castling moves for the king
 func (c *Chessboard) getValidCastlingMoves(color Color, kingPos Position) []Position {
 	validMoves := make([]Position, 0)
 
+	if kingPos != (Position{homeRow(color), 4}) {
+		return validMoves // King is not in the starting position
+	}
+
 	// Check for kingside castling
 	if c.canCastleKingside(color) {
-		kingsidePos := Position{kingPos.Row, kingPos.Col + 2}
-		if !c.isSquareAttacked(kingPos, getOpponentColor(color)) &&
-			!c.isSquareAttacked(kingsidePos, getOpponentColor(color)) &&
-			c.Pieces[kingPos.Row][kingPos.Col+1] == nil &&
-			c.Pieces[kingPos.Row][kingPos.Col+2] == nil {
-			validMoves = append(validMoves, kingsidePos)
-		}
+		validMoves = append(validMoves, Position{kingPos.Row, kingPos.Col + 2})
 	}
 
 	// Check for queenside castling
 	if c.canCastleQueenside(color) {
-		queensidePos := Position{kingPos.Row, kingPos.Col - 2}
-		if !c.isSquareAttacked(kingPos, getOpponentColor(color)) &&
-			!c.isSquareAttacked(queensidePos, getOpponentColor(color)) &&
-			c.Pieces[kingPos.Row][kingPos.Col-1] == nil &&
-			c.Pieces[kingPos.Row][kingPos.Col-2] == nil &&
-			c.Pieces[kingPos.Row][kingPos.Col-3] == nil {
-			validMoves = append(validMoves, queensidePos)
-		}
+		validMoves = append(validMoves, Position{kingPos.Row, kingPos.Col - 2})
 	}
 
 	return validMoves
 }
 
+// homeRow returns the row the pieces of the specified color start on
+func homeRow(color Color) int {
+	if color == White {
+		return 7
+	}
+	return 0
+}
+
 // canCastleKingside checks if kingside castling is allowed for the specified color
 func (c *Chessboard) canCastleKingside(color Color) bool {
-	kingPos := c.findKingPosition(color)
-	if (color == White && kingPos.Row != 0) || (color == Black && kingPos.Row != 7) {
-		return false // King is not in the starting position
+	if color == White {
+		return c.canCastleKingsideWhite()
 	}
-	return c.canCastleKingsideWhite() || c.canCastleKingsideBlack()
+	return c.canCastleKingsideBlack()
 }
 
 // canCastleQueenside checks if queenside castling is allowed for the specified color
 func (c *Chessboard) canCastleQueenside(color Color) bool {
-	kingPos := c.findKingPosition(color)
-	if (color == White && kingPos.Row != 0) || (color == Black && kingPos.Row != 7) {
-		return false // King is not in the starting position
+	if color == White {
+		return c.canCastleQueensideWhite()
 	}
-	return c.canCastleQueensideWhite() || c.canCastleQueensideBlack()
+	return c.canCastleQueensideBlack()
 }
 
 // canCastleKingsideWhite checks if kingside castling is allowed for white
 func (c *Chessboard) canCastleKingsideWhite() bool {
 	// Conditions for kingside castling for white
-	if c.hasPieceMoved(Position{0, 4}) || c.hasPieceMoved(Position{0, 7}) {
+	if !c.isCastlingPieces(White, 7) || c.hasPieceMoved(Position{7, 4}) || c.hasPieceMoved(Position{7, 7}) {
 		return false // King or kingside rook has moved
 	}
-	if c.Pieces[0][5] != nil || c.Pieces[0][6] != nil {
+	if c.Pieces[7][5] != nil || c.Pieces[7][6] != nil {
 		return false // Squares between king and rook are not empty
 	}
-	if c.isSquareAttacked(Position{0, 4}, Black) || c.isSquareAttacked(Position{0, 5}, Black) || c.isSquareAttacked(Position{0, 6}, Black) {
+	if c.isSquareAttacked(Position{7, 4}, Black) || c.isSquareAttacked(Position{7, 5}, Black) || c.isSquareAttacked(Position{7, 6}, Black) {
 		return false // King or castling squares are under attack
 	}
 	return true
@@ -267,13 +173,13 @@ func (c *Chessboard) canCastleKingsideWhite() bool {
 // canCastleQueensideWhite checks if queenside castling is allowed for white
 func (c *Chessboard) canCastleQueensideWhite() bool {
 	// Conditions for queenside castling for white
-	if c.hasPieceMoved(Position{0, 4}) || c.hasPieceMoved(Position{0, 0}) {
+	if !c.isCastlingPieces(White, 0) || c.hasPieceMoved(Position{7, 4}) || c.hasPieceMoved(Position{7, 0}) {
 		return false // King or queenside rook has moved
 	}
-	if c.Pieces[0][1] != nil || c.Pieces[0][2] != nil || c.Pieces[0][3] != nil {
+	if c.Pieces[7][1] != nil || c.Pieces[7][2] != nil || c.Pieces[7][3] != nil {
 		return false // Squares between king and rook are not empty
 	}
-	if c.isSquareAttacked(Position{0, 4}, Black) || c.isSquareAttacked(Position{0, 3}, Black) || c.isSquareAttacked(Position{0, 2}, Black) {
+	if c.isSquareAttacked(Position{7, 4}, Black) || c.isSquareAttacked(Position{7, 3}, Black) || c.isSquareAttacked(Position{7, 2}, Black) {
 		return false // King or castling squares are under attack
 	}
 	return true
@@ -282,13 +188,13 @@ func (c *Chessboard) canCastleQueensideWhite() bool {
 // canCastleKingsideBlack checks if kingside castling is allowed for black
 func (c *Chessboard) canCastleKingsideBlack() bool {
 	// Conditions for kingside castling for black
-	if c.hasPieceMoved(Position{7, 4}) || c.hasPieceMoved(Position{7, 7}) {
+	if !c.isCastlingPieces(Black, 7) || c.hasPieceMoved(Position{0, 4}) || c.hasPieceMoved(Position{0, 7}) {
 		return false // King or kingside rook has moved
 	}
-	if c.Pieces[7][5] != nil || c.Pieces[7][6] != nil {
+	if c.Pieces[0][5] != nil || c.Pieces[0][6] != nil {
 		return false // Squares between king and rook are not empty
 	}
-	if c.isSquareAttacked(Position{7, 4}, White) || c.isSquareAttacked(Position{7, 5}, White) || c.isSquareAttacked(Position{7, 6}, White) {
+	if c.isSquareAttacked(Position{0, 4}, White) || c.isSquareAttacked(Position{0, 5}, White) || c.isSquareAttacked(Position{0, 6}, White) {
 		return false // King or castling squares are under attack
 	}
 	return true
@@ -297,72 +203,60 @@ func (c *Chessboard) canCastleKingsideBlack() bool {
 // canCastleQueensideBlack checks if queenside castling is allowed for black
 func (c *Chessboard) canCastleQueensideBlack() bool {
 	// Conditions for queenside castling for black
-	if c.hasPieceMoved(Position{7, 4}) || c.hasPieceMoved(Position{7, 0}) {
+	if !c.isCastlingPieces(Black, 0) || c.hasPieceMoved(Position{0, 4}) || c.hasPieceMoved(Position{0, 0}) {
 		return false // King or queenside rook has moved
 	}
-	if c.Pieces[7][1] != nil || c.Pieces[7][2] != nil || c.Pieces[7][3] != nil {
+	if c.Pieces[0][1] != nil || c.Pieces[0][2] != nil || c.Pieces[0][3] != nil {
 		return false // Squares between king and rook are not empty
 	}
-	if c.isSquareAttacked(Position{7, 4}, White) || c.isSquareAttacked(Position{7, 3}, White) || c.isSquareAttacked(Position{7, 2}, White) {
+	if c.isSquareAttacked(Position{0, 4}, White) || c.isSquareAttacked(Position{0, 3}, White) || c.isSquareAttacked(Position{0, 2}, White) {
 		return false // King or castling squares are under attack
 	}
 	return true
 }
 
+// isCastlingPieces checks if the king and the rook of the rookCol are on their starting squares
+func (c *Chessboard) isCastlingPieces(color Color, rookCol int) bool {
+	row := homeRow(color)
+	king, rook := c.GetPiece(row, 4), c.GetPiece(row, rookCol)
+
+	return king != nil && king.Type == King && king.Color == color &&
+		rook != nil && rook.Type == Rook && rook.Color == color
+}
+
 // hasPieceMoved checks if a piece at the specified position has moved
 func (c *Chessboard) hasPieceMoved(pos Position) bool {
 	return c.MovesCount[pos.Row][pos.Col] > 0
 }
 
 func (c *Chessboard) isSquareAttacked(square Position, byColor Color) bool {
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			piece := c.Pieces[i][j]
-			if piece != nil && piece.Color == byColor {
-				validMoves := c.calculateValidMoves(piece)
-				for _, move := range validMoves {
-					if move == square {
-						return true
-					}
-				}
-			}
-		}
-	}
-	return false
+	return c.bitboards.isAttacked(squareIndex(square), colorIndex(byColor))
 }
 
 func (c *Chessboard) findKingPosition(color Color) Position {
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			piece := c.Pieces[i][j]
-			if piece != nil && piece.Type == King && piece.Color == color {
-				return Position{i, j}
-			}
-		}
+	king := c.bitboards.pieces[colorIndex(color)][kingIndex]
+	if king == 0 {
+		return Position{-1, -1}
 	}
-	return Position{-1, -1}
+
+	return squarePosition(king.lsb())
 }
 
 func (c *Chessboard) IsInCheck(color Color) bool {
-	kingPos := c.findKingPosition(color)
-	opponentColor := getOpponentColor(color)
+	king := c.bitboards.pieces[colorIndex(color)][kingIndex]
 
 	// Check if any opponent piece can attack the king
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			piece := c.Pieces[i][j]
-			if piece != nil && piece.Color == opponentColor {
-				validMoves := c.calculateValidMoves(piece)
-				for _, move := range validMoves {
-					if move == kingPos {
-						return true
-					}
-				}
-			}
-		}
-	}
+	return king != 0 && c.bitboards.isAttacked(king.lsb(), colorIndex(getOpponentColor(color)))
+}
 
-	return false
+// Clone returns a copy of the board with the history of its positions, so
+// repetitions are still found on the copy. Moves played on the copy do not
+// change the board and the copy has no moves to take back.
+func (c *Chessboard) Clone() *Chessboard {
+	clone := c.cloneBoard()
+	clone.history = append(make([]uint64, 0, len(c.history)), c.history...)
+
+	return clone
 }
 
 func (c *Chessboard) cloneBoard() *Chessboard {
@@ -385,49 +279,88 @@ func (c *Chessboard) cloneBoard() *Chessboard {
 			clone.MovesCount[i][j] = c.MovesCount[i][j]
 		}
 	}
+
+	if c.EnPassant != nil {
+		enPassant := *c.EnPassant
+		clone.EnPassant = &enPassant
+	}
+
+	clone.bitboards = c.bitboards
+	clone.hash = c.hash
+	clone.Turn = c.Turn
+	clone.HalfmoveClock = c.HalfmoveClock
+	clone.FullmoveNumber = c.FullmoveNumber
+
 	return clone
 }
 
+// wouldMoveResultInCheck plays the move on a copy of the bitboards only, the
+// promoted piece type does not matter for the safety of the own king.
 func (c *Chessboard) wouldMoveResultInCheck(color Color, from, to Position) bool {
-	simulatedBoard := c.cloneBoard()
+	var (
+		piece     = c.GetPiece(from.Row, from.Col)
+		bitboards = c.bitboards
+	)
 
-	fromPiece := simulatedBoard.GetPiece(from.Row, from.Col)
-	fromPiece.Position = to
+	if captured := c.GetPiece(to.Row, to.Col); captured != nil {
+		bitboards.clear(captured, squareIndex(to))
+	}
+
+	if c.isEnPassantCapture(piece, to) {
+		captured := Position{from.Row, to.Col}
+		bitboards.clear(c.GetPiece(captured.Row, captured.Col), squareIndex(captured))
+	}
 
-	// move the piece
-	simulatedBoard.Pieces[to.Row][to.Col] = simulatedBoard.Pieces[from.Row][from.Col]
-	simulatedBoard.Pieces[from.Row][from.Col] = nil
-	simulatedBoard.increaseMovesCount(from, to)
+	bitboards.clear(piece, squareIndex(from))
+	bitboards.set(piece, squareIndex(to))
 
-	kingPos := simulatedBoard.findKingPosition(color)
-	return simulatedBoard.isSquareAttacked(kingPos, getOpponentColor(color))
+	king := bitboards.pieces[colorIndex(color)][kingIndex]
+	return king != 0 && bitboards.isAttacked(king.lsb(), colorIndex(getOpponentColor(color)))
 }
 
 func (c *Chessboard) IsCheckmate(color Color) bool {
-	if !c.IsInCheck(color) {
-		return false
+	return c.IsInCheck(color) && !c.hasValidMoves(color)
+}
+
+// hasValidMoves checks if any piece of the color has a legal move
+func (c *Chessboard) hasValidMoves(color Color) bool {
+	for pieces := c.bitboards.occupied[colorIndex(color)]; pieces != 0; {
+		piece := c.getPieceOnSquare(pieces.popLSB())
+
+		for targets := c.getPseudoLegalTargets(piece); targets != 0; {
+			if !c.wouldMoveResultInCheck(color, piece.Position, squarePosition(targets.popLSB())) {
+				return true
+			}
+		}
 	}
 
-	// Iterate through all pieces of the checked color and check if any valid moves can escape check
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			piece := c.Pieces[i][j]
-			if piece != nil && piece.Color == color {
-				for x := 0; x < 8; x++ {
-					for y := 0; y < 8; y++ {
-						if c.IsValidMove(piece, Position{x, y}) {
-							// Try making the move and check if the king is still in check
-							simulatedBoard := c.cloneBoard()
-							simulatedBoard.PlacePiece(piece, Position{x, y})
-							if !simulatedBoard.IsInCheck(color) {
-								return false // King can escape check, not checkmate
-							}
-						}
-					}
-				}
+	// castling is never the only legal move, the king can always step to the rook side square instead
+	return false
+}
+
+// GetAllValidMoves returns every legal move of the color, a pawn reaching
+// the last rank has one move for each piece it can be promoted to
+func (c *Chessboard) GetAllValidMoves(color Color) []*ChessBoardMove {
+	moves := make([]*ChessBoardMove, 0, 48)
+
+	for pieces := c.bitboards.occupied[colorIndex(color)]; pieces != 0; {
+		piece := c.getPieceOnSquare(pieces.popLSB())
+
+		for _, to := range c.GetValidMoves(piece) {
+			if !isPromotionMove(piece, to) {
+				moves = append(moves, &ChessBoardMove{From: piece.Position, To: to})
+				continue
+			}
+
+			for _, promotion := range []PieceType{Queen, Rook, Bishop, Knight} {
+				moves = append(moves, &ChessBoardMove{From: piece.Position, To: to, Promotion: promotion})
 			}
 		}
 	}
 
-	return true // No valid moves to escape check, it's checkmate
+	return moves
+}
+
+func (c *Chessboard) getPieceOnSquare(square int) *Piece {
+	return c.Pieces[square/8][square%8]
 }
@@ -0,0 +1,59 @@
+package chessboard
+
+import "testing"
+
+var benchmarkPositions = []struct {
+	name string
+	fen  string
+}{
+	{"initial", DefaultFEN},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"},
+	{"endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"},
+	{"promotions", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"},
+}
+
+func benchmarkBoards(b *testing.B, fn func(b *testing.B, board *Chessboard)) {
+	for _, position := range benchmarkPositions {
+		board, err := NewFromFEN(position.fen)
+		if err != nil {
+			b.Fatalf("%s: %v", position.name, err)
+		}
+
+		b.Run(position.name, func(b *testing.B) {
+			b.ReportAllocs()
+			fn(b, board)
+		})
+	}
+}
+
+func BenchmarkGetAllValidMoves(b *testing.B) {
+	benchmarkBoards(b, func(b *testing.B, board *Chessboard) {
+		for i := 0; i < b.N; i++ {
+			board.GetAllValidMoves(board.Turn)
+		}
+	})
+}
+
+func BenchmarkIsInCheck(b *testing.B) {
+	benchmarkBoards(b, func(b *testing.B, board *Chessboard) {
+		for i := 0; i < b.N; i++ {
+			board.IsInCheck(board.Turn)
+		}
+	})
+}
+
+func BenchmarkIsCheckmate(b *testing.B) {
+	benchmarkBoards(b, func(b *testing.B, board *Chessboard) {
+		for i := 0; i < b.N; i++ {
+			board.IsCheckmate(board.Turn)
+		}
+	})
+}
+
+func BenchmarkEvaluateResult(b *testing.B) {
+	benchmarkBoards(b, func(b *testing.B, board *Chessboard) {
+		for i := 0; i < b.N; i++ {
+			board.EvaluateResult(board.Turn)
+		}
+	})
+}
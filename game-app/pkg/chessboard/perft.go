@@ -0,0 +1,49 @@
+package chessboard
+
+// Perft counts the leaf nodes of the legal move tree to the depth, the
+// numbers are compared with reference positions to find move generation bugs
+func (c *Chessboard) Perft(depth int) int {
+	if depth <= 0 {
+		return 1
+	}
+
+	moves := c.GetAllValidMoves(c.Turn)
+
+	// bulk counting, the moves of the last ply are not played
+	if depth == 1 {
+		return len(moves)
+	}
+
+	nodes := 0
+
+	for _, move := range moves {
+		nodes += c.perftMove(move, depth)
+	}
+
+	return nodes
+}
+
+// Divide returns the perft of every legal move of the position keyed by the
+// move in UCI notation, the sum is the perft of the position
+func (c *Chessboard) Divide(depth int) map[string]int {
+	nodes := make(map[string]int)
+
+	if depth <= 0 {
+		return nodes
+	}
+
+	for _, move := range c.GetAllValidMoves(c.Turn) {
+		nodes[move.UCI()] = c.perftMove(move, depth)
+	}
+
+	return nodes
+}
+
+// perftMove plays the move, counts the nodes below it and takes it back
+func (c *Chessboard) perftMove(move *ChessBoardMove, depth int) int {
+	c.movePiece(c.GetPiece(move.From.Row, move.From.Col), move.To, move.Promotion)
+	nodes := c.Perft(depth - 1)
+	c.UnmakeMove()
+
+	return nodes
+}
@@ -0,0 +1,167 @@
+package chessboard
+
+import "testing"
+
+// reference positions and node counts from https://www.chessprogramming.org/Perft_Results
+// and the special cases of the perft suite by Martin Sedlak
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes map[int]int
+}{
+	{
+		name:  "initial",
+		fen:   DefaultFEN,
+		nodes: map[int]int{1: 20, 2: 400, 3: 8902, 4: 197281},
+	},
+	{
+		name:  "kiwipete",
+		fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		nodes: map[int]int{1: 48, 2: 2039, 3: 97862},
+	},
+	{
+		name:  "position 3",
+		fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		nodes: map[int]int{1: 14, 2: 191, 3: 2812, 4: 43238, 5: 674624},
+	},
+	{
+		name:  "position 4",
+		fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		nodes: map[int]int{1: 6, 2: 264, 3: 9467, 4: 422333},
+	},
+	{
+		name:  "position 4 mirrored",
+		fen:   "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		nodes: map[int]int{1: 6, 2: 264, 3: 9467, 4: 422333},
+	},
+	{
+		name:  "position 5",
+		fen:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		nodes: map[int]int{1: 44, 2: 1486, 3: 62379},
+	},
+	{
+		name:  "position 6",
+		fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		nodes: map[int]int{1: 46, 2: 2079, 3: 89890},
+	},
+	{
+		name:  "illegal en passant",
+		fen:   "3k4/3p4/8/K1P4r/8/8/8/8 b - - 0 1",
+		nodes: map[int]int{6: 1134888},
+	},
+	{
+		name:  "illegal en passant 2",
+		fen:   "8/8/4k3/8/2p5/8/B2P2K1/8 w - - 0 1",
+		nodes: map[int]int{6: 1015133},
+	},
+	{
+		name:  "en passant capture checks opponent",
+		fen:   "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1",
+		nodes: map[int]int{6: 1440467},
+	},
+	{
+		name:  "short castling gives check",
+		fen:   "5k2/8/8/8/8/8/8/4K2R w K - 0 1",
+		nodes: map[int]int{6: 661072},
+	},
+	{
+		name:  "long castling gives check",
+		fen:   "3k4/8/8/8/8/8/8/R3K3 w Q - 0 1",
+		nodes: map[int]int{6: 803711},
+	},
+	{
+		name:  "castle rights",
+		fen:   "r3k2r/1b4bq/8/8/8/8/7B/R3K2R w KQkq - 0 1",
+		nodes: map[int]int{4: 1274206},
+	},
+	{
+		name:  "castling prevented",
+		fen:   "r3k2r/8/3Q4/8/8/5q2/8/R3K2R b KQkq - 0 1",
+		nodes: map[int]int{4: 1720476},
+	},
+	{
+		name:  "promote out of check",
+		fen:   "2K2r2/4P3/8/8/8/8/8/3k4 w - - 0 1",
+		nodes: map[int]int{6: 3821001},
+	},
+	{
+		name:  "discovered check",
+		fen:   "8/8/1P2K3/8/2n5/1q6/8/5k2 b - - 0 1",
+		nodes: map[int]int{5: 1004658},
+	},
+	{
+		name:  "promote to give check",
+		fen:   "4k3/1P6/8/8/8/8/K7/8 w - - 0 1",
+		nodes: map[int]int{6: 217342},
+	},
+	{
+		name:  "under promote to give check",
+		fen:   "8/P1k5/K7/8/8/8/8/8 w - - 0 1",
+		nodes: map[int]int{6: 92683},
+	},
+	{
+		name:  "self stalemate",
+		fen:   "K1k5/8/P7/8/8/8/8/8 w - - 0 1",
+		nodes: map[int]int{6: 2217},
+	},
+	{
+		name:  "stalemate and checkmate",
+		fen:   "8/k1P5/8/1K6/8/8/8/8 w - - 0 1",
+		nodes: map[int]int{7: 567584},
+	},
+	{
+		name:  "stalemate and checkmate 2",
+		fen:   "8/8/2k5/5q2/5n2/8/5K2/8 b - - 0 1",
+		nodes: map[int]int{4: 23527},
+	},
+}
+
+func TestPerft(t *testing.T) {
+	for _, position := range perftPositions {
+		position := position
+
+		t.Run(position.name, func(t *testing.T) {
+			board, err := NewFromFEN(position.fen)
+			if err != nil {
+				t.Fatalf("NewFromFEN(%q) failed: %v", position.fen, err)
+			}
+
+			for depth, expected := range position.nodes {
+				// the deep counts take a while, they are skipped with -short
+				if testing.Short() && expected > 100000 {
+					continue
+				}
+
+				if nodes := board.Perft(depth); nodes != expected {
+					t.Errorf("perft(%d) = %d, want %d", depth, nodes, expected)
+				}
+			}
+
+			if fen := board.FEN(); fen != position.fen {
+				t.Errorf("perft changed the position to %q", fen)
+			}
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	board := NewDefault()
+
+	divide := board.Divide(3)
+	if len(divide) != 20 {
+		t.Fatalf("divide(3) has %d moves, want 20", len(divide))
+	}
+
+	total := 0
+	for _, nodes := range divide {
+		total += nodes
+	}
+
+	if total != 8902 {
+		t.Errorf("divide(3) sums to %d, want 8902", total)
+	}
+
+	if divide["e2e4"] != 600 {
+		t.Errorf("divide(3) e2e4 = %d, want 600", divide["e2e4"])
+	}
+}
@@ -1,6 +1,9 @@
 package chessboard
 
-import "strconv"
+import (
+	"strconv"
+	"strings"
+)
 
 type PieceType string
 
@@ -13,6 +16,24 @@ const (
 	King   PieceType = "K"
 )
 
+// ParsePromotion parses the piece a pawn is promoted to, an empty value means no promotion
+func ParsePromotion(value string) (PieceType, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	pieceType := PieceType(strings.ToUpper(value))
+	if !isValidPromotion(pieceType) {
+		return "", ErrInvalidPromotion
+	}
+
+	return pieceType, nil
+}
+
+func isValidPromotion(pieceType PieceType) bool {
+	return pieceType == Queen || pieceType == Rook || pieceType == Bishop || pieceType == Knight
+}
+
 type Color string
 
 const (
@@ -25,29 +46,9 @@ type Position struct {
 	Col int `json:"col"`
 }
 
+// String returns the algebraic name of the position, row 0 is the 8th rank and col 0 is the a-file
 func (p Position) String() string {
-	var str string
-
-	switch p.Row {
-	case 0:
-		str = "a"
-	case 1:
-		str = "b"
-	case 2:
-		str = "c"
-	case 3:
-		str = "d"
-	case 4:
-		str = "e"
-	case 5:
-		str = "g"
-	case 6:
-		str = "h"
-	case 7:
-		str = "f"
-	}
-
-	return str + strconv.Itoa(p.Col+1)
+	return string(rune('a'+p.Col)) + strconv.Itoa(8-p.Row)
 }
 
 type Piece struct {
@@ -61,6 +61,10 @@ func (c *Chessboard) isValidMovePawn(piece *Piece, from, to Position) bool {
 			!isToEmptyPiece && toPiece.Color != piece.Color {
 			return true
 		}
+		// Capturing en passant
+		if to.Row == from.Row-1 && abs(to.Col-from.Col) == 1 && c.isEnPassantCapture(piece, to) {
+			return true
+		}
 	} else {
 		// Moving one square forward
 		if to.Row == from.Row+1 && to.Col == from.Col && isToEmptyPiece {
@@ -75,6 +79,10 @@ func (c *Chessboard) isValidMovePawn(piece *Piece, from, to Position) bool {
 			!isToEmptyPiece && toPiece.Color != piece.Color {
 			return true
 		}
+		// Capturing en passant
+		if to.Row == from.Row+1 && abs(to.Col-from.Col) == 1 && c.isEnPassantCapture(piece, to) {
+			return true
+		}
 	}
 
 	return false
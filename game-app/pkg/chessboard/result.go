@@ -0,0 +1,49 @@
+package chessboard
+
+type Termination string
+
+const (
+	TerminationCheckmate            Termination = "checkmate"
+	TerminationStalemate            Termination = "stalemate"
+	TerminationInsufficientMaterial Termination = "insufficient_material"
+	TerminationFivefoldRepetition   Termination = "fivefold_repetition"
+	TerminationSeventyFiveMoveRule  Termination = "seventy_five_move_rule"
+	TerminationThreefoldRepetition  Termination = "threefold_repetition"
+	TerminationFiftyMoveRule        Termination = "fifty_move_rule"
+)
+
+type GameResult struct {
+	// Winner is the color that won the game, nil when the game is drawn
+	Winner      *Color
+	Termination Termination
+}
+
+func (r *GameResult) IsDraw() bool {
+	return r.Winner == nil
+}
+
+// EvaluateResult evaluates the game with the color being the side to move,
+// it returns nil as long as the game goes on. Checkmate and stalemate come
+// before the automatic draws, a mate on the 75th move still wins the game.
+func (c *Chessboard) EvaluateResult(color Color) *GameResult {
+	if c.hasValidMoves(color) {
+		return c.evaluateAutomaticDraw()
+	}
+
+	if c.IsInCheck(color) {
+		winner := getOpponentColor(color)
+
+		return &GameResult{
+			Winner:      &winner,
+			Termination: TerminationCheckmate,
+		}
+	}
+
+	return &GameResult{
+		Termination: TerminationStalemate,
+	}
+}
+
+func (c *Chessboard) IsStalemate(color Color) bool {
+	return !c.IsInCheck(color) && !c.hasValidMoves(color)
+}
@@ -0,0 +1,216 @@
+package chessboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SAN returns the standard algebraic notation of moving the piece on from
+// to the position, the move is not played and has to be a legal one
+func (c *Chessboard) SAN(from, to Position, promotion PieceType) (string, error) {
+	piece := c.GetPiece(from.Row, from.Col)
+	if piece == nil {
+		return "", fmt.Errorf("there is no piece in %s", from)
+	}
+
+	if !c.isLegalMove(piece, to) {
+		return "", fmt.Errorf("this is not a valid move from %s to %s", from, to)
+	}
+
+	var sb strings.Builder
+
+	switch {
+	case piece.Type == King && abs(to.Col-from.Col) == 2:
+		if to.Col > from.Col {
+			sb.WriteString(string(KingsideCastling))
+		} else {
+			sb.WriteString(string(QueensideCastling))
+		}
+
+	case piece.Type == Pawn:
+		if from.Col != to.Col {
+			sb.WriteByte(byte('a' + from.Col))
+			sb.WriteByte('x')
+		}
+
+		sb.WriteString(to.String())
+
+		if promotion != "" {
+			sb.WriteByte('=')
+			sb.WriteString(string(promotion))
+		}
+
+	default:
+		sb.WriteString(string(piece.Type))
+		sb.WriteString(c.sanDisambiguation(piece, to))
+
+		if !c.isEmptyPiece(to.Row, to.Col) {
+			sb.WriteByte('x')
+		}
+
+		sb.WriteString(to.String())
+	}
+
+	// check and checkmate suffix
+	simulatedBoard := c.cloneBoard()
+	simulatedBoard.movePiece(simulatedBoard.GetPiece(from.Row, from.Col), to, promotion)
+
+	opponent := getOpponentColor(piece.Color)
+	if simulatedBoard.IsInCheck(opponent) {
+		if simulatedBoard.hasValidMoves(opponent) {
+			sb.WriteByte('+')
+		} else {
+			sb.WriteByte('#')
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// sanDisambiguation returns the file, the rank or both of the piece when
+// another piece of the same type can legally move to the same square
+func (c *Chessboard) sanDisambiguation(piece *Piece, to Position) string {
+	var (
+		ambiguous = false
+		sameFile  = false
+		sameRank  = false
+	)
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			other := c.Pieces[i][j]
+			if other == nil || other == piece || other.Type != piece.Type || other.Color != piece.Color {
+				continue
+			}
+
+			if !c.isLegalMove(other, to) {
+				continue
+			}
+
+			ambiguous = true
+			sameFile = sameFile || other.Position.Col == piece.Position.Col
+			sameRank = sameRank || other.Position.Row == piece.Position.Row
+		}
+	}
+
+	square := piece.Position.String()
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	default:
+		return square
+	}
+}
+
+// ParseSAN parses a move in standard algebraic notation for the side to move.
+// Check marks and annotations are ignored, 0-0 is accepted for O-O and a
+// promotion can be written with or without the equal sign.
+func (c *Chessboard) ParseSAN(san string) (*ChessBoardMove, error) {
+	notation := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+	notation = strings.TrimSpace(strings.TrimSuffix(notation, "e.p."))
+	notation = strings.ReplaceAll(notation, "0", "O")
+
+	invalid := fmt.Errorf("invalid move %q", san)
+
+	if notation == string(KingsideCastling) || notation == string(QueensideCastling) {
+		row := homeRow(c.Turn)
+		to := Position{row, 6}
+		if notation == string(QueensideCastling) {
+			to = Position{row, 2}
+		}
+
+		king := c.GetPiece(row, 4)
+		if king == nil || king.Type != King || king.Color != c.Turn || !c.isLegalMove(king, to) {
+			return nil, invalid
+		}
+
+		return &ChessBoardMove{From: king.Position, To: to}, nil
+	}
+
+	var (
+		pieceType = Pawn
+		promotion PieceType
+	)
+
+	if len(notation) > 0 && strings.ContainsRune("RNBQK", rune(notation[0])) {
+		pieceType = PieceType(notation[:1])
+		notation = notation[1:]
+	}
+
+	// promotion, e8=Q or e8Q
+	if len(notation) > 2 && strings.ContainsRune("RNBQ", rune(notation[len(notation)-1])) {
+		promotion = PieceType(notation[len(notation)-1:])
+		notation = strings.TrimSuffix(notation[:len(notation)-1], "=")
+	}
+
+	if len(notation) < 2 {
+		return nil, invalid
+	}
+
+	to, err := GetPosition(notation[len(notation)-2:])
+	if err != nil {
+		return nil, invalid
+	}
+
+	// what is left is the disambiguation and the capture mark
+	var (
+		disambiguation = strings.ReplaceAll(notation[:len(notation)-2], "x", "")
+		fromCol        = -1
+		fromRow        = -1
+	)
+
+	for _, ch := range disambiguation {
+		switch {
+		case ch >= 'a' && ch <= 'h':
+			fromCol = int(ch - 'a')
+		case ch >= '1' && ch <= '8':
+			fromRow = 8 - int(ch-'0')
+		default:
+			return nil, invalid
+		}
+	}
+
+	var move *ChessBoardMove
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			piece := c.Pieces[i][j]
+			if piece == nil || piece.Color != c.Turn || piece.Type != pieceType ||
+				(fromCol != -1 && j != fromCol) || (fromRow != -1 && i != fromRow) {
+				continue
+			}
+
+			if !c.isLegalMove(piece, *to) {
+				continue
+			}
+
+			if move != nil {
+				return nil, fmt.Errorf("ambiguous move %q", san)
+			}
+
+			move = &ChessBoardMove{From: piece.Position, To: *to, Promotion: promotion}
+		}
+	}
+
+	if move == nil {
+		return nil, invalid
+	}
+
+	return move, nil
+}
+
+// isLegalMove checks if the piece can legally move to the position
+func (c *Chessboard) isLegalMove(piece *Piece, to Position) bool {
+	for _, move := range c.GetValidMoves(piece) {
+		if move == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package chessboard
+
+import "testing"
+
+var sanTests = []struct {
+	name      string
+	fen       string
+	from      string
+	to        string
+	promotion PieceType
+	san       string
+}{
+	{"pawn push", DefaultFEN, "e2", "e4", "", "e4"},
+	{"knight", DefaultFEN, "g1", "f3", "", "Nf3"},
+	{"piece capture", "4k3/8/8/4p3/8/5N2/8/4K3 w - - 0 1", "f3", "e5", "", "Nxe5"},
+
+	// both knights reach d2
+	{"file disambiguation", "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "b1", "d2", "", "Nbd2"},
+	// both rooks reach a3
+	{"rank disambiguation", "4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", "a1", "a3", "", "R1a3"},
+	// the queen on e1 shares the file and the one on h4 the rank
+	{"square disambiguation", "8/8/k7/8/4Q2Q/8/K7/4Q3 w - - 0 1", "e4", "h1", "", "Qe4h1"},
+
+	{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5", "d6", "", "exd6"},
+	{"promotion with check", "8/4P3/8/8/k7/8/8/4K3 w - - 0 1", "e7", "e8", Queen, "e8=Q+"},
+	{"checkmate", "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", "a1", "a8", "", "Ra8#"},
+
+	{"white kingside castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1", "g1", "", "O-O"},
+	{"white queenside castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1", "c1", "", "O-O-O"},
+	{"black kingside castling", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8", "g8", "", "O-O"},
+	{"black queenside castling", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8", "c8", "", "O-O-O"},
+}
+
+func TestSAN(t *testing.T) {
+	for _, test := range sanTests {
+		t.Run(test.name, func(t *testing.T) {
+			board, err := NewFromFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			from, _ := GetPosition(test.from)
+			to, _ := GetPosition(test.to)
+
+			san, err := board.SAN(*from, *to, test.promotion)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if san != test.san {
+				t.Errorf("expected %s, got %s", test.san, san)
+			}
+		})
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	for _, test := range sanTests {
+		t.Run(test.name, func(t *testing.T) {
+			board, err := NewFromFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			move, err := board.ParseSAN(test.san)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if move.From.String() != test.from || move.To.String() != test.to || move.Promotion != test.promotion {
+				t.Errorf("expected %s%s%s, got %s%s%s", test.from, test.to, test.promotion, move.From, move.To, move.Promotion)
+			}
+		})
+	}
+}
+
+func TestParseSANVariants(t *testing.T) {
+	tests := []struct {
+		fen  string
+		san  string
+		from string
+		to   string
+	}{
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "e1", "g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "0-0-0", "e8", "c8"},
+		{"8/4P3/8/8/k7/8/8/4K3 w - - 0 1", "e8Q", "e7", "e8"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6 e.p.", "e5", "d6"},
+		{"4k3/8/8/4p3/8/5N2/8/4K3 w - - 0 1", "Nxe5!?", "f3", "e5"},
+	}
+
+	for _, test := range tests {
+		board, err := NewFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fen, err)
+		}
+
+		move, err := board.ParseSAN(test.san)
+		if err != nil {
+			t.Fatalf("%s: %v", test.san, err)
+		}
+
+		if move.From.String() != test.from || move.To.String() != test.to {
+			t.Errorf("%s: expected %s%s, got %s%s", test.san, test.from, test.to, move.From, move.To)
+		}
+	}
+}
+
+func TestParseSANRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		san  string
+	}{
+		{"ambiguous file", "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "Nd2"},
+		{"ambiguous rank", "4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", "Ra3"},
+		{"ambiguous square", "8/8/k7/8/4Q2Q/8/K7/4Q3 w - - 0 1", "Qeh1"},
+		{"unreachable square", DefaultFEN, "e5"},
+		{"blocked piece", DefaultFEN, "Qh5"},
+		{"wrong side", DefaultFEN, "Nf6"},
+		{"castling through pieces", DefaultFEN, "O-O"},
+		{"castling without rights", "r3k2r/8/8/8/8/8/8/R3K2R w - - 0 1", "O-O-O"},
+		{"pinned piece", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", "Nf4"},
+		{"no square", DefaultFEN, "N"},
+		{"off the board", DefaultFEN, "Ni9"},
+		{"garbage", DefaultFEN, "hello"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			board, err := NewFromFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if move, err := board.ParseSAN(test.san); err == nil {
+				t.Errorf("expected %s to be rejected, got %s%s", test.san, move.From, move.To)
+			}
+		})
+	}
+}
+
+func TestSANIllegalMove(t *testing.T) {
+	board := NewDefault()
+
+	if san, err := board.SAN(Position{6, 4}, Position{3, 4}, ""); err == nil {
+		t.Errorf("expected e2e5 to be rejected, got %s", san)
+	}
+}
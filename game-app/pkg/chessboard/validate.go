@@ -0,0 +1,35 @@
+package chessboard
+
+// Validate checks that the position can be played from: each side has one
+// king, the side that just moved did not leave its king in check and no pawn
+// stands on the first or the last rank. A FEN only describes a position, it
+// does not make it legal.
+func (c *Chessboard) Validate() error {
+	var kings [2]int
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			piece := c.Pieces[i][j]
+			if piece == nil {
+				continue
+			}
+
+			switch {
+			case piece.Type == King:
+				kings[colorIndex(piece.Color)]++
+			case piece.Type == Pawn && (i == 0 || i == 7):
+				return ErrPawnOnBackRank
+			}
+		}
+	}
+
+	if kings[0] != 1 || kings[1] != 1 {
+		return ErrInvalidKings
+	}
+
+	if c.IsInCheck(getOpponentColor(c.Turn)) {
+		return ErrOpponentInCheck
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package chessboard
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected error
+	}{
+		{DefaultFEN, nil},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1", nil},
+		{"4k3/8/8/8/8/8/8/4K3 b - - 0 1", nil},
+
+		{"8/8/8/8/8/8/8/4K3 w - - 0 1", ErrInvalidKings},
+		{"4k3/8/8/8/8/8/8/3KK3 w - - 0 1", ErrInvalidKings},
+		{"P3k3/8/8/8/8/8/8/4K3 w - - 0 1", ErrPawnOnBackRank},
+		{"4k3/8/8/8/8/8/8/p3K3 w - - 0 1", ErrPawnOnBackRank},
+
+		// the rook on h1 checks the white king, which only white to move can answer
+		{"4k3/8/8/8/8/8/8/4K2r w - - 0 1", nil},
+		{"4k3/8/8/8/8/8/8/4K2r b - - 0 1", ErrOpponentInCheck},
+	}
+
+	for _, test := range tests {
+		board, err := NewFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fen, err)
+		}
+
+		if err := board.Validate(); err != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.fen, test.expected, err)
+		}
+	}
+}
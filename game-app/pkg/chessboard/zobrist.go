@@ -0,0 +1,113 @@
+package chessboard
+
+import "slices"
+
+// the zobrist keys are generated from a fixed seed so a hash stays the same
+// across restarts and can be stored next to the game or used as a cache key
+const zobristSeed uint64 = 0x9E3779B97F4A7C15
+
+const (
+	whiteKingsideCastling = 1 << iota
+	whiteQueensideCastling
+	blackKingsideCastling
+	blackQueensideCastling
+)
+
+var (
+	zobristPieces      [2][6][64]uint64
+	zobristBlackToMove uint64
+	zobristCastling    [16]uint64
+	zobristEnPassant   [8]uint64
+)
+
+func init() {
+	state := zobristSeed
+
+	// xorshift64*
+	next := func() uint64 {
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		return state * 2685821657736338717
+	}
+
+	for color := range zobristPieces {
+		for piece := range zobristPieces[color] {
+			for square := range zobristPieces[color][piece] {
+				zobristPieces[color][piece][square] = next()
+			}
+		}
+	}
+
+	zobristBlackToMove = next()
+
+	// one key for every right, a combination of rights is the xor of their keys
+	var rights [4]uint64
+	for i := range rights {
+		rights[i] = next()
+	}
+
+	for mask := range zobristCastling {
+		for i, key := range rights {
+			if mask&(1<<i) != 0 {
+				zobristCastling[mask] ^= key
+			}
+		}
+	}
+
+	for file := range zobristEnPassant {
+		zobristEnPassant[file] = next()
+	}
+}
+
+// Hash returns the zobrist hash of the position, it covers the pieces, the
+// side to move, the castling rights and the en passant file when a pawn can
+// take en passant. Equal positions in the sense of the repetition rules have equal hashes.
+func (c *Chessboard) Hash() uint64 {
+	return c.hash
+}
+
+// History returns the hash of every position reached in the game, the current one last
+func (c *Chessboard) History() []uint64 {
+	return slices.Clone(c.history)
+}
+
+func zobristPiece(piece *Piece, square int) uint64 {
+	return zobristPieces[colorIndex(piece.Color)][pieceIndex(piece.Type)][square]
+}
+
+// computeHash works the hash out from scratch, moves keep it up to date incrementally
+func (c *Chessboard) computeHash() uint64 {
+	var hash uint64
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			if piece := c.Pieces[i][j]; piece != nil {
+				hash ^= zobristPiece(piece, squareIndex(Position{i, j}))
+			}
+		}
+	}
+
+	return hash ^ c.stateHash()
+}
+
+// stateHash is the part of the hash that does not come from the pieces
+func (c *Chessboard) stateHash() uint64 {
+	hash := zobristCastling[c.castlingRightsMask()]
+
+	if c.Turn == Black {
+		hash ^= zobristBlackToMove
+	}
+
+	if c.EnPassant != nil && c.canCaptureEnPassant() {
+		hash ^= zobristEnPassant[c.EnPassant.Col]
+	}
+
+	return hash
+}
+
+// resetHistory recomputes the hash and starts the position history from the current position
+func (c *Chessboard) resetHistory() {
+	c.hash = c.computeHash()
+	c.history = []uint64{c.hash}
+}
@@ -0,0 +1,86 @@
+package chessboard
+
+import "testing"
+
+// checkHash walks the move tree and compares the incremental hash with one computed from scratch
+func checkHash(t *testing.T, board *Chessboard, depth int) {
+	t.Helper()
+
+	if hash := board.computeHash(); board.Hash() != hash {
+		t.Fatalf("incremental hash %x differs from computed hash %x in %s", board.Hash(), hash, board.FEN())
+	}
+
+	if depth == 0 {
+		return
+	}
+
+	for _, move := range board.GetAllValidMoves(board.Turn) {
+		before := board.Hash()
+
+		if _, err := board.MakeMove(move); err != nil {
+			t.Fatalf("MakeMove(%s) failed in %s: %v", move.UCI(), board.FEN(), err)
+		}
+
+		checkHash(t, board, depth-1)
+
+		if _, err := board.UnmakeMove(); err != nil {
+			t.Fatalf("UnmakeMove failed: %v", err)
+		}
+
+		if board.Hash() != before {
+			t.Fatalf("UnmakeMove(%s) did not restore the hash in %s", move.UCI(), board.FEN())
+		}
+	}
+}
+
+func TestHashIsIncremental(t *testing.T) {
+	for _, position := range perftPositions[:7] {
+		board, err := NewFromFEN(position.fen)
+		if err != nil {
+			t.Fatalf("NewFromFEN(%q) failed: %v", position.fen, err)
+		}
+
+		checkHash(t, board, 3)
+	}
+}
+
+func TestHashTransposition(t *testing.T) {
+	board := NewDefault()
+	start := board.Hash()
+
+	for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8"} {
+		move, err := board.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("ParseSAN(%q) failed: %v", san, err)
+		}
+
+		if _, err := board.MakeMove(move); err != nil {
+			t.Fatalf("MakeMove(%q) failed: %v", san, err)
+		}
+	}
+
+	if board.Hash() != start {
+		t.Errorf("the same position has a different hash after the knights went back")
+	}
+
+	if board.RepetitionCount() != 2 {
+		t.Errorf("RepetitionCount() = %d, want 2", board.RepetitionCount())
+	}
+
+	fromFEN, err := NewFromFEN(board.FEN())
+	if err != nil {
+		t.Fatalf("NewFromFEN failed: %v", err)
+	}
+
+	if fromFEN.Hash() != board.Hash() {
+		t.Errorf("the board from FEN has a different hash")
+	}
+
+	// a double push without a pawn to take en passant leaves the hash alone
+	withoutEnPassant, _ := NewFromFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	withEnPassant, _ := NewFromFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+
+	if withoutEnPassant.Hash() != withEnPassant.Hash() {
+		t.Errorf("an en passant square nobody can take on changed the hash")
+	}
+}
@@ -0,0 +1,190 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+// startPlies is the number of moves played before the clocks start to run,
+// the first move of each side is free
+const startPlies = 2
+
+// TimeControl is the time of each side and what a move gives back. A move
+// gets the Fischer increment added after it is played, the Bronstein delay
+// gives back the time the move took up to the delay.
+type TimeControl struct {
+	Initial   time.Duration
+	Increment time.Duration
+	Delay     time.Duration
+}
+
+// State is what it takes to restore a clock
+type State struct {
+	// White and Black are the time left when the turn of the side to move started
+	White time.Duration
+	Black time.Duration
+
+	Turn chessboard.Color
+
+	// TurnStartedAt is when the clock of the side to move started, nil while the clocks do not run
+	TurnStartedAt *time.Time
+}
+
+// Clock is a chess clock, only the side to move has a running clock. A copy
+// of the value is a snapshot of the clock.
+type Clock struct {
+	control TimeControl
+
+	// white and black are the time left of every side when its last turn started
+	white time.Duration
+	black time.Duration
+
+	turn          chessboard.Color
+	turnStartedAt time.Time
+	running       bool
+
+	// plies is the number of moves played, up to the start of the clocks
+	plies int
+}
+
+// New returns a clock with the initial time on both sides, the clocks start once each side moved
+func New(control TimeControl, turn chessboard.Color) *Clock {
+	return &Clock{
+		control: control,
+		white:   control.Initial,
+		black:   control.Initial,
+		turn:    turn,
+	}
+}
+
+// Restore returns the clock of the state, plies is the number of moves played in the game
+func Restore(control TimeControl, state State, plies int) *Clock {
+	clock := New(control, state.Turn)
+	clock.white = state.White
+	clock.black = state.Black
+	clock.plies = plies
+
+	if state.TurnStartedAt != nil {
+		clock.running = true
+		clock.turnStartedAt = *state.TurnStartedAt
+	}
+
+	return clock
+}
+
+func (c *Clock) Control() TimeControl {
+	return c.control
+}
+
+// Running reports whether the clock of the side to move is running
+func (c *Clock) Running() bool {
+	return c.running
+}
+
+// Turn is the side whose clock runs
+func (c *Clock) Turn() chessboard.Color {
+	return c.turn
+}
+
+// Remaining returns the time the color has left at the time
+func (c *Clock) Remaining(color chessboard.Color, now time.Time) time.Duration {
+	remaining := *c.side(color)
+
+	if c.running && color == c.turn {
+		remaining -= now.Sub(c.turnStartedAt)
+	}
+
+	return max(remaining, 0)
+}
+
+// Flagged reports whether the side to move has run out of time
+func (c *Clock) Flagged(now time.Time) bool {
+	return c.running && c.Remaining(c.turn, now) <= 0
+}
+
+// Expiry returns how long the side to move has until it runs out of time, 0 while the clocks do not run
+func (c *Clock) Expiry(now time.Time) time.Duration {
+	if !c.running {
+		return 0
+	}
+
+	return c.Remaining(c.turn, now)
+}
+
+// Press ends the turn of the side to move after its move and starts the
+// clock of the opponent. It returns false without touching the clock when
+// the side to move has already run out of time.
+func (c *Clock) Press(now time.Time) bool {
+	if c.Flagged(now) {
+		return false
+	}
+
+	if c.running {
+		elapsed := now.Sub(c.turnStartedAt)
+
+		*c.side(c.turn) += c.control.Increment + min(elapsed, c.control.Delay) - elapsed
+	}
+
+	c.plies++
+	c.turn = opponent(c.turn)
+
+	if c.plies >= startPlies {
+		c.running = true
+		c.turnStartedAt = now
+	}
+
+	return true
+}
+
+// TakeBack gives the turn back to the side that played the last move, the
+// time used so far stays used and the increment of the move is not taken away
+func (c *Clock) TakeBack(now time.Time) {
+	if c.running {
+		*c.side(c.turn) = c.Remaining(c.turn, now)
+		c.turnStartedAt = now
+	}
+
+	c.plies = max(c.plies-1, 0)
+	c.turn = opponent(c.turn)
+}
+
+// Stop stops the clock of the side to move at the time
+func (c *Clock) Stop(now time.Time) {
+	if !c.running {
+		return
+	}
+
+	*c.side(c.turn) = c.Remaining(c.turn, now)
+	c.running = false
+}
+
+// State returns the state to restore the clock from
+func (c *Clock) State() State {
+	state := State{
+		White: c.white,
+		Black: c.black,
+		Turn:  c.turn,
+	}
+
+	if c.running {
+		startedAt := c.turnStartedAt
+		state.TurnStartedAt = &startedAt
+	}
+
+	return state
+}
+
+func (c *Clock) side(color chessboard.Color) *time.Duration {
+	if color == chessboard.White {
+		return &c.white
+	}
+	return &c.black
+}
+
+func opponent(color chessboard.Color) chessboard.Color {
+	if color == chessboard.White {
+		return chessboard.Black
+	}
+	return chessboard.White
+}
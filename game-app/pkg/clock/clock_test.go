@@ -0,0 +1,132 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func at(seconds float64) time.Time {
+	return start.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func TestClockStartsAfterBothFirstMoves(t *testing.T) {
+	clock := New(TimeControl{Initial: time.Minute}, chessboard.White)
+
+	clock.Press(at(10))
+	clock.Press(at(30))
+
+	if !clock.Running() {
+		t.Fatal("the clock does not run after both first moves")
+	}
+
+	if white, black := clock.Remaining(chessboard.White, at(30)), clock.Remaining(chessboard.Black, at(30)); white != time.Minute || black != time.Minute {
+		t.Errorf("the first moves used time, white %s black %s", white, black)
+	}
+
+	if white := clock.Remaining(chessboard.White, at(45)); white != 45*time.Second {
+		t.Errorf("expected 45s for white, got %s", white)
+	}
+}
+
+func TestClockIncrement(t *testing.T) {
+	clock := New(TimeControl{Initial: time.Minute, Increment: 2 * time.Second}, chessboard.White)
+
+	clock.Press(at(0))
+	clock.Press(at(0))
+	clock.Press(at(10))
+
+	if white := clock.Remaining(chessboard.White, at(20)); white != 52*time.Second {
+		t.Errorf("expected 60s - 10s + 2s, got %s", white)
+	}
+
+	if black := clock.Remaining(chessboard.Black, at(20)); black != 50*time.Second {
+		t.Errorf("expected black to run for 10s, got %s", black)
+	}
+}
+
+func TestClockDelay(t *testing.T) {
+	clock := New(TimeControl{Initial: time.Minute, Delay: 5 * time.Second}, chessboard.White)
+
+	clock.Press(at(0))
+	clock.Press(at(0))
+
+	// a quick move gets all its time back, a slow one only the delay
+	clock.Press(at(3))
+	clock.Press(at(13))
+
+	if white := clock.Remaining(chessboard.White, at(13)); white != time.Minute {
+		t.Errorf("expected white to keep its minute, got %s", white)
+	}
+
+	if black := clock.Remaining(chessboard.Black, at(13)); black != 55*time.Second {
+		t.Errorf("expected 60s - 10s + 5s, got %s", black)
+	}
+}
+
+func TestClockFlag(t *testing.T) {
+	clock := New(TimeControl{Initial: 10 * time.Second, Increment: time.Second}, chessboard.White)
+
+	clock.Press(at(0))
+	clock.Press(at(0))
+
+	if expiry := clock.Expiry(at(4)); expiry != 6*time.Second {
+		t.Errorf("expected white to flag in 6s, got %s", expiry)
+	}
+
+	if clock.Flagged(at(9.9)) {
+		t.Error("white flagged before its time was up")
+	}
+
+	if !clock.Flagged(at(10)) {
+		t.Error("white did not flag when its time was up")
+	}
+
+	if clock.Press(at(11)) {
+		t.Error("a flagged side could still press the clock")
+	}
+
+	if clock.Turn() != chessboard.White || clock.Remaining(chessboard.White, at(11)) != 0 {
+		t.Error("pressing a flagged clock changed it")
+	}
+}
+
+func TestClockRestore(t *testing.T) {
+	clock := New(TimeControl{Initial: time.Minute}, chessboard.White)
+
+	clock.Press(at(0))
+	clock.Press(at(0))
+	clock.Press(at(20))
+
+	restored := Restore(clock.Control(), clock.State(), 3)
+
+	for _, color := range []chessboard.Color{chessboard.White, chessboard.Black} {
+		if expected, got := clock.Remaining(color, at(30)), restored.Remaining(color, at(30)); expected != got {
+			t.Errorf("%s: expected %s, got %s", color, expected, got)
+		}
+	}
+}
+
+func TestClockTakeBack(t *testing.T) {
+	clock := New(TimeControl{Initial: time.Minute}, chessboard.White)
+
+	clock.Press(at(0))
+	clock.Press(at(0))
+	clock.Press(at(10))
+	clock.TakeBack(at(15))
+
+	if clock.Turn() != chessboard.White {
+		t.Fatal("the turn did not go back to white")
+	}
+
+	if black := clock.Remaining(chessboard.Black, at(15)); black != 55*time.Second {
+		t.Errorf("expected black to keep the time it used, got %s", black)
+	}
+
+	if white := clock.Remaining(chessboard.White, at(20)); white != 45*time.Second {
+		t.Errorf("expected the white clock to run again, got %s", white)
+	}
+}
@@ -0,0 +1,150 @@
+package eco
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+// the table is split by the volumes of the Encyclopaedia of Chess Openings, every
+// file has a header line and then the code, the name and the moves of a line in columns
+//
+//go:embed *.tsv
+var openingFS embed.FS
+
+type Opening struct {
+	// ECO is the code of the opening, like B90
+	ECO  string
+	Name string
+
+	// Moves are the moves of the line in standard algebraic notation
+	Moves []string
+}
+
+var (
+	// openings maps the zobrist hash of the position at the end of a line to its opening
+	openings map[uint64]*Opening
+	loadOnce sync.Once
+)
+
+func load() {
+	var err error
+
+	if openings, err = readOpenings(openingFS); err != nil {
+		panic(fmt.Sprintf("eco: %v", err))
+	}
+}
+
+func readOpenings(fsys fs.FS) (map[uint64]*Opening, error) {
+	files, err := fs.Glob(fsys, "*.tsv")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint64]*Opening)
+
+	for _, file := range files {
+		if err := readFile(fsys, file, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func readFile(fsys fs.FS, name string, result map[uint64]*Opening) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	// skip the header
+	scanner.Scan()
+
+	for line := 2; scanner.Scan(); line++ {
+		columns := strings.Split(scanner.Text(), "\t")
+		if len(columns) != 3 {
+			return fmt.Errorf("%s:%d: expected 3 columns, got %d", name, line, len(columns))
+		}
+
+		opening := &Opening{
+			ECO:   columns[0],
+			Name:  columns[1],
+			Moves: parseMoves(columns[2]),
+		}
+
+		hash, err := replay(opening.Moves)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		// a position reached by transposition keeps the first line that leads to it
+		if _, ok := result[hash]; !ok {
+			result[hash] = opening
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseMoves drops the move numbers of the movetext
+func parseMoves(movetext string) []string {
+	var moves []string
+
+	for _, token := range strings.Fields(movetext) {
+		if !strings.HasSuffix(token, ".") {
+			moves = append(moves, token)
+		}
+	}
+
+	return moves
+}
+
+// replay plays the moves from the start position and returns the hash of the position they reach
+func replay(moves []string) (uint64, error) {
+	board := chessboard.NewDefault()
+
+	for _, san := range moves {
+		move, err := board.ParseSAN(san)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+			return 0, fmt.Errorf("move %s: %w", san, err)
+		}
+	}
+
+	return board.Hash(), nil
+}
+
+// Lookup returns the opening whose line ends in the position with the hash, nil when no line does
+func Lookup(hash uint64) *Opening {
+	loadOnce.Do(load)
+
+	return openings[hash]
+}
+
+// Classify returns the opening of the game on the board, that is the opening
+// of the last position of the game that is in the table. Positions are
+// matched rather than moves so a game that transposes into a line is
+// classified as that line. nil when the game never reached a known position.
+func Classify(board *chessboard.Chessboard) *Opening {
+	history := board.History()
+
+	for i := len(history) - 1; i >= 0; i-- {
+		if opening := Lookup(history[i]); opening != nil {
+			return opening
+		}
+	}
+
+	return nil
+}
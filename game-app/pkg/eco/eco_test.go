@@ -0,0 +1,73 @@
+package eco
+
+import (
+	"testing"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+func TestReadOpenings(t *testing.T) {
+	result, err := readOpenings(openingFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) == 0 {
+		t.Fatal("the table has no openings")
+	}
+
+	for _, opening := range result {
+		if len(opening.ECO) != 3 || opening.ECO[0] < 'A' || opening.ECO[0] > 'E' {
+			t.Errorf("%s: invalid ECO code %q", opening.Name, opening.ECO)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		moves []string
+		eco   string
+		name  string
+	}{
+		{[]string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6"}, "B90", "Sicilian Defense: Najdorf Variation"},
+
+		// the line goes on past the table, the last known position counts
+		{[]string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6", "h3", "e5"}, "B90", "Sicilian Defense: Najdorf Variation"},
+
+		// 1. c4 Nf6 2. Nc3 g6 3. d4 d5 transposes into the Grünfeld
+		{[]string{"c4", "Nf6", "Nc3", "g6", "d4", "d5"}, "D80", "Grünfeld Defense"},
+
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "O-O", "c3", "d5"}, "C89", "Ruy Lopez: Marshall Attack"},
+	}
+
+	for _, test := range tests {
+		board := chessboard.NewDefault()
+
+		for _, san := range test.moves {
+			move, err := board.ParseSAN(san)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		opening := Classify(board)
+		if opening == nil {
+			t.Errorf("%v: no opening, expected %s", test.moves, test.eco)
+			continue
+		}
+
+		if opening.ECO != test.eco || opening.Name != test.name {
+			t.Errorf("%v: got %s %s, expected %s %s", test.moves, opening.ECO, opening.Name, test.eco, test.name)
+		}
+	}
+}
+
+func TestClassifyUnknown(t *testing.T) {
+	if opening := Classify(chessboard.NewDefault()); opening != nil {
+		t.Errorf("the start position is classified as %s %s", opening.ECO, opening.Name)
+	}
+}
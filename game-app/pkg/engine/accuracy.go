@@ -0,0 +1,26 @@
+package engine
+
+import "math"
+
+// maxReviewScore caps the scores of a review, a mate in one and a mate in
+// ten lose the same game, the difference between them is no mistake
+const maxReviewScore = 1000
+
+// ClampScore caps the score to the range a review compares moves in
+func ClampScore(score int) int {
+	return max(-maxReviewScore, min(maxReviewScore, score))
+}
+
+// WinPercent turns a score in centipawns into the chance to win the game
+// from 0 to 100, a position up a pawn or two is won far more often than not.
+func WinPercent(score int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(ClampScore(score))))-1)
+}
+
+// MoveAccuracy rates a move from 0 to 100 by how much of the chance to win it
+// gave away, a move keeping the chance is 100 and a move throwing a won game away is close to 0.
+func MoveAccuracy(winBefore, winAfter float64) float64 {
+	accuracy := 103.1668*math.Exp(-0.04354*(winBefore-winAfter)) - 3.1669
+
+	return math.Max(0, math.Min(100, accuracy))
+}
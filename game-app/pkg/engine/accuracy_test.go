@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWinPercent(t *testing.T) {
+	if got := WinPercent(0); got != 50 {
+		t.Errorf("WinPercent(0) = %f, want 50", got)
+	}
+
+	if got := WinPercent(300) + WinPercent(-300); math.Abs(got-100) > 1e-9 {
+		t.Errorf("the win percents of the sides add up to %f", got)
+	}
+
+	if WinPercent(MateScore(1)) != WinPercent(maxReviewScore) {
+		t.Error("a mate is not capped")
+	}
+
+	if WinPercent(100) <= 50 || WinPercent(500) <= WinPercent(100) {
+		t.Error("a better score does not win more often")
+	}
+}
+
+func TestMoveAccuracy(t *testing.T) {
+	if got := MoveAccuracy(60, 60); math.Abs(got-100) > 0.01 {
+		t.Errorf("keeping the win percent scores %f, want 100", got)
+	}
+
+	if got := MoveAccuracy(60, 80); got != 100 {
+		t.Errorf("improving the win percent scores %f, want 100", got)
+	}
+
+	if got := MoveAccuracy(95, 5); got > 5 {
+		t.Errorf("throwing the game away scores %f", got)
+	}
+}
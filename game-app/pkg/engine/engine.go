@@ -0,0 +1,525 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+const (
+	// infinity is above any score the search can return
+	infinity = 1000000
+
+	// mateScore is the score of checkmating on the current move, a mate in n
+	// plies scores mateScore - n so the search prefers the quickest mate
+	mateScore = 100000
+
+	// maxDepth bounds the iterative deepening when only a time budget is given
+	maxDepth = 64
+
+	// checkNodes is how often the search looks at the clock and the context
+	checkNodes = 2048
+
+	// tableSize is the number of entries of the transposition table
+	tableSize = 1 << 18
+)
+
+// Mated is the score of the side to move when it is checkmated
+const Mated = -mateScore
+
+var ErrNoLegalMoves = errors.New("there is no legal move in the position")
+
+// Options is the budget of a search, at least one of Depth and MoveTime should be set
+type Options struct {
+	// Depth is the number of plies searched, 0 searches until MoveTime is used up
+	Depth int
+
+	// MoveTime stops the search after the time, 0 means no time limit
+	MoveTime time.Duration
+
+	// MultiPV is the number of best moves to find, 0 and 1 find only the best one
+	MultiPV int
+
+	// Noise is the maximum random number of centipawns added to the score of
+	// every root move, it lets weak levels miss the best move now and then
+	Noise int
+}
+
+// Result is the best move the search found
+type Result struct {
+	Move *chessboard.ChessBoardMove
+
+	// Score is the evaluation in centipawns from the point of view of the side to move
+	Score int
+
+	// Depth is the number of plies of the deepest completed iteration
+	Depth int
+
+	// Nodes is the number of positions visited
+	Nodes int
+
+	// PV is the principal variation, the line the search expects to be played
+	PV []*chessboard.ChessBoardMove
+
+	// Lines are the best moves with their scores and variations, the best one
+	// first. There are as many as Options.MultiPV asked for, if there are enough legal moves.
+	Lines []Line
+}
+
+// Line is a root move with its score and principal variation
+type Line struct {
+	Move  *chessboard.ChessBoardMove
+	Score int
+	PV    []*chessboard.ChessBoardMove
+}
+
+// IsMate checks if the score is a forced mate for one of the sides
+func (r *Result) IsMate() bool {
+	return MateIn(r.Score) != 0
+}
+
+// MateIn returns the number of moves to mate of the score, negative when the
+// side to move is mated and 0 when the score is no mate
+func MateIn(score int) int {
+	switch {
+	case score > mateScore-maxDepth*2:
+		return (mateScore - score + 1) / 2
+	case score < -mateScore+maxDepth*2:
+		return -(mateScore + score) / 2
+	default:
+		return 0
+	}
+}
+
+// MateScore is the score of a mate in the number of moves, the inverse of MateIn
+func MateScore(mate int) int {
+	if mate > 0 {
+		return mateScore - (2*mate - 1)
+	}
+	return -mateScore - 2*mate
+}
+
+// Search looks for the best move of the side to move with an iterative
+// deepening alpha-beta search. The board is not changed, the search plays
+// its moves on a clone. The search stops when the depth is reached, the move
+// time is used up or the context is done, the deepest completed iteration is returned.
+func Search(ctx context.Context, board *chessboard.Chessboard, opts Options) (*Result, error) {
+	s := &searcher{
+		ctx:   ctx,
+		board: board.Clone(),
+		table: make([]tableEntry, tableSize),
+	}
+
+	if opts.MoveTime > 0 {
+		s.deadline = time.Now().Add(opts.MoveTime)
+	}
+
+	depth := opts.Depth
+	if depth <= 0 {
+		depth = maxDepth
+	}
+
+	moves := s.board.GetAllValidMoves(s.board.Turn)
+	if len(moves) == 0 {
+		return nil, ErrNoLegalMoves
+	}
+
+	multiPV := min(max(opts.MultiPV, 1), len(moves))
+
+	// the noise of a move stays the same across the iterations
+	var noise map[uint32]int
+	if opts.Noise > 0 {
+		noise = make(map[uint32]int, len(moves))
+		for _, move := range moves {
+			noise[moveKey(move)] = rand.Intn(opts.Noise + 1)
+		}
+	}
+
+	s.orderMoves(moves, nil)
+
+	var result *Result
+
+	for d := 1; d <= depth; d++ {
+		scored, ok := s.searchRoot(moves, noise, d, multiPV)
+		if !ok {
+			break
+		}
+
+		result = &Result{
+			Depth: d,
+			Nodes: s.nodes,
+			Lines: make([]Line, multiPV),
+		}
+
+		for i := range result.Lines {
+			result.Lines[i] = Line{
+				Move:  scored[i].move,
+				Score: scored[i].score,
+				PV:    s.principalVariation(scored[i].move, d),
+			}
+		}
+
+		result.Move, result.Score, result.PV = result.Lines[0].Move, result.Lines[0].Score, result.Lines[0].PV
+
+		// the next iteration starts with the best moves of this one
+		for i := range scored {
+			moves[i] = scored[i].move
+		}
+
+		// there is nothing to find behind a forced mate
+		if multiPV == 1 && result.IsMate() {
+			break
+		}
+	}
+
+	// the first iteration was interrupted, any legal move beats no move
+	if result == nil {
+		result = &Result{
+			Move:  moves[0],
+			Nodes: s.nodes,
+			PV:    moves[:1],
+			Lines: []Line{{Move: moves[0], PV: moves[:1]}},
+		}
+	}
+
+	return result, nil
+}
+
+type boundType uint8
+
+const (
+	exactBound boundType = iota
+	lowerBound
+	upperBound
+)
+
+type tableEntry struct {
+	hash  uint64
+	move  *chessboard.ChessBoardMove
+	score int
+	depth int
+	bound boundType
+}
+
+type searcher struct {
+	ctx      context.Context
+	board    *chessboard.Chessboard
+	table    []tableEntry
+	deadline time.Time
+	nodes    int
+	stopped  bool
+}
+
+func (s *searcher) entry() *tableEntry {
+	return &s.table[s.board.Hash()%tableSize]
+}
+
+// stop checks every few thousand nodes if the search is out of time
+func (s *searcher) stop() bool {
+	if s.stopped {
+		return true
+	}
+
+	if s.nodes%checkNodes == 0 {
+		if s.ctx.Err() != nil || (!s.deadline.IsZero() && time.Now().After(s.deadline)) {
+			s.stopped = true
+		}
+	}
+
+	return s.stopped
+}
+
+type rootMove struct {
+	move  *chessboard.ChessBoardMove
+	score int
+}
+
+// searchRoot searches every root move to the depth and returns them with the
+// best first. Only the first multiPV scores are exact, the others are upper
+// bounds. ok is false when the iteration was interrupted and its result can not be trusted.
+func (s *searcher) searchRoot(moves []*chessboard.ChessBoardMove, noise map[uint32]int, depth, multiPV int) ([]rootMove, bool) {
+	var (
+		scored = make([]rootMove, 0, len(moves))
+		alpha  = -infinity
+	)
+
+	for _, move := range moves {
+		if _, err := s.board.MakeMove(move); err != nil {
+			continue
+		}
+
+		score := -s.negamax(depth-1, 1, -infinity, -alpha)
+		s.board.UnmakeMove()
+
+		if s.stopped {
+			return nil, false
+		}
+
+		scored = append(scored, rootMove{move, score + noise[moveKey(move)]})
+
+		sort.SliceStable(scored, func(i, j int) bool {
+			return scored[i].score > scored[j].score
+		})
+
+		// a move has to beat the last of the best moves to be one of them
+		if len(scored) >= multiPV {
+			alpha = scored[multiPV-1].score
+		}
+	}
+
+	s.store(scored[0].move, scored[0].score, depth, exactBound)
+
+	return scored, true
+}
+
+func (s *searcher) negamax(depth, ply, alpha, beta int) int {
+	s.nodes++
+	if s.stop() {
+		return 0
+	}
+
+	if s.board.HalfmoveClock >= 100 || s.board.RepetitionCount() >= 2 {
+		return 0
+	}
+
+	inCheck := s.board.IsInCheck(s.board.Turn)
+
+	// a check is searched one ply deeper so mates behind it are not missed
+	if inCheck {
+		depth++
+	}
+
+	if depth <= 0 {
+		return s.quiescence(ply, alpha, beta)
+	}
+
+	var (
+		hash      = s.board.Hash()
+		entry     = s.entry()
+		tableMove = entry.moveFor(hash)
+	)
+
+	if entry.hash == hash && entry.depth >= depth {
+		switch {
+		case entry.bound == exactBound,
+			entry.bound == lowerBound && entry.score >= beta,
+			entry.bound == upperBound && entry.score <= alpha:
+			return entry.score
+		}
+	}
+
+	moves := s.board.GetAllValidMoves(s.board.Turn)
+	if len(moves) == 0 {
+		if inCheck {
+			return -mateScore + ply
+		}
+		return 0
+	}
+
+	s.orderMoves(moves, tableMove)
+
+	var (
+		bestMove  *chessboard.ChessBoardMove
+		bestScore = -infinity
+		bound     = upperBound
+	)
+
+	for _, move := range moves {
+		if _, err := s.board.MakeMove(move); err != nil {
+			continue
+		}
+
+		score := -s.negamax(depth-1, ply+1, -beta, -alpha)
+		s.board.UnmakeMove()
+
+		if s.stopped {
+			return 0
+		}
+
+		if score > bestScore {
+			bestScore, bestMove = score, move
+		}
+
+		if score > alpha {
+			alpha, bound = score, exactBound
+		}
+
+		if alpha >= beta {
+			bound = lowerBound
+			break
+		}
+	}
+
+	s.store(bestMove, bestScore, depth, bound)
+
+	return bestScore
+}
+
+// quiescence only searches captures and promotions, so the evaluation is
+// never taken in the middle of an exchange
+func (s *searcher) quiescence(ply, alpha, beta int) int {
+	s.nodes++
+	if s.stop() {
+		return 0
+	}
+
+	standPat := Evaluate(s.board)
+	if standPat >= beta {
+		return standPat
+	}
+
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	moves := s.board.GetAllValidMoves(s.board.Turn)
+	if len(moves) == 0 {
+		if s.board.IsInCheck(s.board.Turn) {
+			return -mateScore + ply
+		}
+		return 0
+	}
+
+	tactical := moves[:0]
+	for _, move := range moves {
+		if s.isCapture(move) || move.Promotion == chessboard.Queen {
+			tactical = append(tactical, move)
+		}
+	}
+
+	s.orderMoves(tactical, nil)
+
+	for _, move := range tactical {
+		if _, err := s.board.MakeMove(move); err != nil {
+			continue
+		}
+
+		score := -s.quiescence(ply+1, -beta, -alpha)
+		s.board.UnmakeMove()
+
+		if s.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+func (s *searcher) store(move *chessboard.ChessBoardMove, score, depth int, bound boundType) {
+	entry := s.entry()
+
+	// a deeper result of another position is worth more than a shallow one of this position
+	if entry.hash != s.board.Hash() && entry.depth > depth {
+		return
+	}
+
+	*entry = tableEntry{
+		hash:  s.board.Hash(),
+		move:  move,
+		score: score,
+		depth: depth,
+		bound: bound,
+	}
+}
+
+func (e *tableEntry) moveFor(hash uint64) *chessboard.ChessBoardMove {
+	if e.hash == hash {
+		return e.move
+	}
+	return nil
+}
+
+// principalVariation follows the best moves of the transposition table from the root
+func (s *searcher) principalVariation(move *chessboard.ChessBoardMove, depth int) []*chessboard.ChessBoardMove {
+	var (
+		pv     = []*chessboard.ChessBoardMove{move}
+		played = 0
+	)
+
+	for len(pv) < depth {
+		if _, err := s.board.MakeMove(pv[len(pv)-1]); err != nil {
+			break
+		}
+		played++
+
+		next := s.entry().moveFor(s.board.Hash())
+		if next == nil || s.board.RepetitionCount() >= 2 {
+			break
+		}
+
+		pv = append(pv, next)
+	}
+
+	for ; played > 0; played-- {
+		s.board.UnmakeMove()
+	}
+
+	return pv
+}
+
+func (s *searcher) isCapture(move *chessboard.ChessBoardMove) bool {
+	return s.board.GetPiece(move.To.Row, move.To.Col) != nil || s.isEnPassant(move)
+}
+
+func (s *searcher) isEnPassant(move *chessboard.ChessBoardMove) bool {
+	piece := s.board.GetPiece(move.From.Row, move.From.Col)
+	return piece != nil && piece.Type == chessboard.Pawn && move.From.Col != move.To.Col &&
+		s.board.GetPiece(move.To.Row, move.To.Col) == nil
+}
+
+// orderMoves puts the best move first, then captures of the most valuable
+// piece by the least valuable attacker (MVV-LVA), then promotions
+func (s *searcher) orderMoves(moves []*chessboard.ChessBoardMove, best *chessboard.ChessBoardMove) {
+	scores := make(map[*chessboard.ChessBoardMove]int, len(moves))
+
+	for _, move := range moves {
+		var score int
+
+		switch {
+		case best != nil && sameMove(move, best):
+			score = infinity
+		case s.isEnPassant(move):
+			score = 10 * pieceValues[chessboard.Pawn]
+		default:
+			if captured := s.board.GetPiece(move.To.Row, move.To.Col); captured != nil {
+				attacker := s.board.GetPiece(move.From.Row, move.From.Col)
+				score = 10*pieceValues[captured.Type] - pieceValues[attacker.Type]/10
+			}
+		}
+
+		if move.Promotion != "" {
+			score += pieceValues[move.Promotion]
+		}
+
+		scores[move] = score
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return scores[moves[i]] > scores[moves[j]]
+	})
+}
+
+func sameMove(a, b *chessboard.ChessBoardMove) bool {
+	return a.From == b.From && a.To == b.To && a.Promotion == b.Promotion
+}
+
+// moveKey packs the squares and the promotion of the move into a number
+func moveKey(move *chessboard.ChessBoardMove) uint32 {
+	key := uint32(move.From.Row*8+move.From.Col)<<6 | uint32(move.To.Row*8+move.To.Col)
+	if move.Promotion != "" {
+		key |= uint32(move.Promotion[0]) << 12
+	}
+	return key
+}
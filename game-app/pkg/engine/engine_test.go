@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+func TestSearchFindsMate(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+	}{
+		{"back rank", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8"},
+		{"scholar", "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "f3f7"},
+		{"promotion", "7k/4P3/6K1/8/8/8/8/8 w - - 0 1", "e7e8q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := chessboard.NewFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Search(context.Background(), board, Options{Depth: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := result.Move.UCI(); got != tt.move {
+				t.Errorf("got %s, want %s", got, tt.move)
+			}
+
+			if !result.IsMate() {
+				t.Errorf("score %d is not a mate", result.Score)
+			}
+		})
+	}
+}
+
+func TestSearchWinsMaterial(t *testing.T) {
+	// the knight forks the king and the queen
+	board, err := chessboard.NewFromFEN("2q1k3/8/8/8/4N3/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Search(context.Background(), board, Options{Depth: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.Move.UCI(); got != "e4d6" {
+		t.Errorf("got %s, want e4d6", got)
+	}
+}
+
+func TestSearchStopsOnMoveTime(t *testing.T) {
+	board := chessboard.NewDefault()
+
+	start := time.Now()
+	result, err := Search(context.Background(), board, Options{MoveTime: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("search took %s", elapsed)
+	}
+
+	if result.Move == nil {
+		t.Fatal("no move")
+	}
+
+	if board.FEN() != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Errorf("search changed the board: %s", board.FEN())
+	}
+}
+
+func TestSearchMultiPV(t *testing.T) {
+	board := chessboard.NewDefault()
+
+	result, err := Search(context.Background(), board, Options{Depth: 3, MultiPV: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(result.Lines))
+	}
+
+	seen := make(map[string]bool)
+	for i, line := range result.Lines {
+		if seen[line.Move.UCI()] {
+			t.Errorf("line %d repeats %s", i+1, line.Move.UCI())
+		}
+		seen[line.Move.UCI()] = true
+
+		if i > 0 && line.Score > result.Lines[i-1].Score {
+			t.Errorf("line %d scores %d, more than the line before", i+1, line.Score)
+		}
+
+		if len(line.PV) == 0 || line.PV[0] != line.Move {
+			t.Errorf("line %d has a wrong variation", i+1)
+		}
+	}
+
+	if result.Move != result.Lines[0].Move || result.Score != result.Lines[0].Score {
+		t.Error("the result is not the first line")
+	}
+}
+
+func TestMateIn(t *testing.T) {
+	for _, mate := range []int{1, 2, 5, -1, -3} {
+		if got := MateIn(MateScore(mate)); got != mate {
+			t.Errorf("MateIn(MateScore(%d)) = %d", mate, got)
+		}
+	}
+
+	if got := MateIn(250); got != 0 {
+		t.Errorf("MateIn(250) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import "github.com/esmailemami/chess/game/pkg/chessboard"
+
+// pieceValues is the material value of every piece type in centipawns
+var pieceValues = map[chessboard.PieceType]int{
+	chessboard.Pawn:   100,
+	chessboard.Knight: 320,
+	chessboard.Bishop: 330,
+	chessboard.Rook:   500,
+	chessboard.Queen:  900,
+	chessboard.King:   0,
+}
+
+// the piece-square tables give a bonus for a piece standing on a square, they
+// are written from the point of view of white with row 0 being the 8th rank
+var (
+	pawnTable = [8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	}
+
+	knightTable = [8][8]int{
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	}
+
+	bishopTable = [8][8]int{
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	}
+
+	rookTable = [8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	}
+
+	queenTable = [8][8]int{
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	}
+
+	// the king hides behind its pawns in the middlegame
+	kingMiddlegameTable = [8][8]int{
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	}
+
+	// and walks to the center in the endgame
+	kingEndgameTable = [8][8]int{
+		{-50, -40, -30, -20, -20, -30, -40, -50},
+		{-30, -20, -10, 0, 0, -10, -20, -30},
+		{-30, -10, 20, 30, 30, 20, -10, -30},
+		{-30, -10, 30, 40, 40, 30, -10, -30},
+		{-30, -10, 30, 40, 40, 30, -10, -30},
+		{-30, -10, 20, 30, 30, 20, -10, -30},
+		{-30, -30, 0, 0, 0, 0, -30, -30},
+		{-50, -30, -30, -30, -30, -30, -30, -50},
+	}
+)
+
+// endgameMaterial is the material of the pieces other than pawns and kings
+// below which the kings use the endgame table
+const endgameMaterial = 1300
+
+// Evaluate returns the static evaluation of the position in centipawns from
+// the point of view of the side to move, it counts the material and where the pieces stand.
+func Evaluate(board *chessboard.Chessboard) int {
+	var (
+		score    int
+		material [2]int
+		kings    [2]*chessboard.Piece
+	)
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			piece := board.Pieces[i][j]
+			if piece == nil {
+				continue
+			}
+
+			side := sideIndex(piece.Color)
+
+			if piece.Type == chessboard.King {
+				kings[side] = piece
+				continue
+			}
+
+			value := pieceValues[piece.Type] + squareBonus(piece, pieceTable(piece.Type))
+
+			if piece.Type != chessboard.Pawn {
+				material[side] += pieceValues[piece.Type]
+			}
+
+			if piece.Color == chessboard.White {
+				score += value
+			} else {
+				score -= value
+			}
+		}
+	}
+
+	for side, king := range kings {
+		if king == nil {
+			continue
+		}
+
+		table := &kingMiddlegameTable
+		if material[1-side] <= endgameMaterial {
+			table = &kingEndgameTable
+		}
+
+		if king.Color == chessboard.White {
+			score += squareBonus(king, table)
+		} else {
+			score -= squareBonus(king, table)
+		}
+	}
+
+	if board.Turn == chessboard.Black {
+		return -score
+	}
+
+	return score
+}
+
+func pieceTable(pieceType chessboard.PieceType) *[8][8]int {
+	switch pieceType {
+	case chessboard.Pawn:
+		return &pawnTable
+	case chessboard.Knight:
+		return &knightTable
+	case chessboard.Bishop:
+		return &bishopTable
+	case chessboard.Rook:
+		return &rookTable
+	default:
+		return &queenTable
+	}
+}
+
+// squareBonus looks the piece up in the table, black reads it upside down
+func squareBonus(piece *chessboard.Piece, table *[8][8]int) int {
+	row := piece.Position.Row
+	if piece.Color == chessboard.Black {
+		row = 7 - row
+	}
+
+	return table[row][piece.Position.Col]
+}
+
+func sideIndex(color chessboard.Color) int {
+	if color == chessboard.White {
+		return 0
+	}
+	return 1
+}
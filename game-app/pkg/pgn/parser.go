@@ -0,0 +1,187 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Parse reads every game of a PGN file. Comments, variations, numeric
+// annotation glyphs and move numbers are skipped, only the mainline moves
+// are kept.
+func Parse(r io.Reader) ([]*Game, error) {
+	bts, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		text  = []rune(string(bts))
+		games = make([]*Game, 0)
+		game  *Game
+		// a tag after the movetext starts the next game
+		inMovetext = false
+	)
+
+	current := func() *Game {
+		if game == nil {
+			game = &Game{}
+		}
+		return game
+	}
+
+	finish := func() {
+		if game != nil {
+			games = append(games, game)
+		}
+		game = nil
+		inMovetext = false
+	}
+
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+
+		switch {
+		case unicode.IsSpace(ch):
+			continue
+
+		case ch == '%' && (i == 0 || text[i-1] == '\n'):
+			// escape mechanism, the rest of the line is ignored
+			i = skipUntil(text, i, '\n')
+
+		case ch == ';':
+			i = skipUntil(text, i, '\n')
+
+		case ch == '{':
+			end := skipUntil(text, i, '}')
+			if end == len(text) {
+				return nil, fmt.Errorf("%w: unterminated comment", ErrInvalidPGN)
+			}
+			i = end
+
+		case ch == '(':
+			end, err := skipVariation(text, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+
+		case ch == '[':
+			if inMovetext {
+				finish()
+			}
+
+			end := skipUntil(text, i, ']')
+			if end == len(text) {
+				return nil, fmt.Errorf("%w: unterminated tag", ErrInvalidPGN)
+			}
+
+			tag, err := parseTag(string(text[i+1 : end]))
+			if err != nil {
+				return nil, err
+			}
+
+			current().SetTag(tag.Name, tag.Value)
+			i = end
+
+		default:
+			start := i
+			for i < len(text) && !unicode.IsSpace(text[i]) && !strings.ContainsRune("{}()[];", text[i]) {
+				i++
+			}
+
+			token := string(text[start:i])
+			i--
+
+			inMovetext = true
+
+			switch token = stripMoveNumber(token); token {
+			case "":
+				continue
+
+			case ResultWhiteWon, ResultBlackWon, ResultDraw, ResultUnknown:
+				if current().GetTag("Result") == "" {
+					current().SetTag("Result", token)
+				}
+				finish()
+
+			default:
+				if strings.HasPrefix(token, "$") {
+					continue
+				}
+
+				current().Moves = append(current().Moves, token)
+			}
+		}
+	}
+
+	finish()
+
+	if len(games) == 0 {
+		return nil, ErrNoGame
+	}
+
+	return games, nil
+}
+
+func parseTag(content string) (*Tag, error) {
+	content = strings.TrimSpace(content)
+
+	space := strings.IndexFunc(content, unicode.IsSpace)
+	if space <= 0 {
+		return nil, fmt.Errorf("%w: invalid tag [%s]", ErrInvalidPGN, content)
+	}
+
+	value := strings.TrimSpace(content[space:])
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, fmt.Errorf("%w: invalid tag [%s]", ErrInvalidPGN, content)
+	}
+
+	value = value[1 : len(value)-1]
+	value = strings.ReplaceAll(value, `\"`, `"`)
+	value = strings.ReplaceAll(value, `\\`, `\`)
+
+	return &Tag{Name: content[:space], Value: value}, nil
+}
+
+// stripMoveNumber removes a leading move number like 12. or 12... from the token
+func stripMoveNumber(token string) string {
+	i := 0
+	for i < len(token) && token[i] >= '0' && token[i] <= '9' {
+		i++
+	}
+
+	if i == 0 || i == len(token) || token[i] != '.' {
+		return token
+	}
+
+	return strings.TrimLeft(token[i:], ".")
+}
+
+func skipUntil(text []rune, i int, end rune) int {
+	for i < len(text) && text[i] != end {
+		i++
+	}
+	return i
+}
+
+func skipVariation(text []rune, i int) (int, error) {
+	depth := 0
+
+	for ; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			i = skipUntil(text, i, '}')
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return i, fmt.Errorf("%w: unterminated variation", ErrInvalidPGN)
+}
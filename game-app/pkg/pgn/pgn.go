@@ -0,0 +1,193 @@
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+const (
+	ResultWhiteWon = "1-0"
+	ResultBlackWon = "0-1"
+	ResultDraw     = "1/2-1/2"
+	ResultUnknown  = "*"
+
+	// the export format keeps movetext lines under 80 characters
+	maxLineLength = 79
+)
+
+var (
+	ErrNoGame       = errors.New("the PGN has no game")
+	ErrInvalidPGN   = errors.New("invalid PGN")
+	sevenTagRoster  = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+	sevenTagDefault = map[string]string{"Event": "?", "Site": "?", "Date": "????.??.??", "Round": "?", "White": "?", "Black": "?", "Result": ResultUnknown}
+)
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+type Game struct {
+	Tags []Tag
+
+	// Moves are the moves of the game in standard algebraic notation
+	Moves []string
+}
+
+// GetTag returns the value of the tag, empty when the game does not have it
+func (g *Game) GetTag(name string) string {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+// SetTag adds the tag or replaces its value
+func (g *Game) SetTag(name, value string) {
+	for i, tag := range g.Tags {
+		if tag.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// Result returns the result of the game, * when it is unknown
+func (g *Game) Result() string {
+	switch result := g.GetTag("Result"); result {
+	case ResultWhiteWon, ResultBlackWon, ResultDraw:
+		return result
+	default:
+		return ResultUnknown
+	}
+}
+
+// String encodes the game in the PGN export format, the Seven Tag Roster
+// comes first and in its fixed order followed by any other tag
+func (g *Game) String() string {
+	var sb strings.Builder
+
+	for _, name := range sevenTagRoster {
+		value := g.GetTag(name)
+		if value == "" {
+			value = sevenTagDefault[name]
+		}
+
+		writeTag(&sb, name, value)
+	}
+
+	for _, tag := range g.Tags {
+		if isSevenTagRoster(tag.Name) {
+			continue
+		}
+
+		writeTag(&sb, tag.Name, tag.Value)
+	}
+
+	sb.WriteByte('\n')
+
+	// a game set up from a position where black is to move starts with 1...
+	var (
+		moveNumber  = 1
+		blackToMove = false
+		tokens      = make([]string, 0, len(g.Moves)+1)
+	)
+
+	if fen := g.GetTag("FEN"); fen != "" {
+		if board, err := chessboard.NewFromFEN(fen); err == nil {
+			moveNumber = board.FullmoveNumber
+			blackToMove = board.Turn == chessboard.Black
+		}
+	}
+
+	for i, move := range g.Moves {
+		switch {
+		case !blackToMove:
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		case i == 0:
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+
+		tokens = append(tokens, move)
+
+		if blackToMove {
+			moveNumber++
+		}
+		blackToMove = !blackToMove
+	}
+
+	tokens = append(tokens, g.Result())
+
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > maxLineLength {
+				sb.WriteByte('\n')
+				lineLength = 0
+			} else {
+				sb.WriteByte(' ')
+				lineLength++
+			}
+		}
+
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// Replay plays the moves on a board from the starting position, the FEN tag
+// when the game has one. It returns the final board and the moves in order.
+func (g *Game) Replay() (*chessboard.Chessboard, []*chessboard.ChessBoardMove, error) {
+	board := chessboard.NewDefault()
+
+	if fen := g.GetTag("FEN"); fen != "" {
+		var err error
+		if board, err = chessboard.NewFromFEN(fen); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	moves := make([]*chessboard.ChessBoardMove, len(g.Moves))
+
+	for i, san := range g.Moves {
+		move, err := board.ParseSAN(san)
+		if err != nil {
+			return nil, nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+
+		if moves[i], err = board.PlacePieceFromPosition(move.From, move.To, move.Promotion); err != nil {
+			return nil, nil, fmt.Errorf("move %d (%s): %w", i+1, san, err)
+		}
+	}
+
+	return board, moves, nil
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, value)
+}
+
+func isSevenTagRoster(name string) bool {
+	for _, tag := range sevenTagRoster {
+		if tag == name {
+			return true
+		}
+	}
+
+	return false
+}
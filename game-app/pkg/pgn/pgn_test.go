@@ -0,0 +1,259 @@
+package pgn
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		pgn   string
+		tag   string
+		value string
+	}{
+		{"plain", `[Event "Casual Game"] 1. e4 *`, "Event", "Casual Game"},
+		{"escaped quote", `[Annotator "the \"best\" player"] 1. e4 *`, "Annotator", `the "best" player`},
+		{"escaped backslash", `[Site "C:\\chess"] 1. e4 *`, "Site", `C:\chess`},
+		{"spaces around", "[  White   \"Morphy, Paul\"  ]\n1. e4 *", "White", "Morphy, Paul"},
+		{"empty value", `[Round ""] 1. e4 *`, "Round", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			games, err := Parse(strings.NewReader(test.pgn))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if value := games[0].GetTag(test.tag); value != test.value {
+				t.Errorf("expected %s %q, got %q", test.tag, test.value, value)
+			}
+		})
+	}
+}
+
+func TestParseInvalidTags(t *testing.T) {
+	tests := []string{
+		`[Event] 1. e4 *`,
+		`[Event Casual] 1. e4 *`,
+		`[Event "Casual" 1. e4 *`,
+	}
+
+	for _, pgn := range tests {
+		if _, err := Parse(strings.NewReader(pgn)); !errors.Is(err, ErrInvalidPGN) {
+			t.Errorf("%s: expected ErrInvalidPGN, got %v", pgn, err)
+		}
+	}
+}
+
+func TestParseMovetext(t *testing.T) {
+	tests := []struct {
+		name  string
+		pgn   string
+		moves []string
+	}{
+		{"move numbers", "1. e4 e5 2. Nf3 Nc6 *", []string{"e4", "e5", "Nf3", "Nc6"}},
+		{"numbers without space", "1.e4 e5 2.Nf3 *", []string{"e4", "e5", "Nf3"}},
+		{"black move number", "1... e5 2. Nf3 *", []string{"e5", "Nf3"}},
+		{"brace comment", "1. e4 {the king's pawn} e5 *", []string{"e4", "e5"}},
+		{"line comment", "1. e4 ; best by test\ne5 *", []string{"e4", "e5"}},
+		{"escape line", "% exported by a tool\n1. e4 e5 *", []string{"e4", "e5"}},
+		{"variation", "1. e4 (1. d4 d5) e5 *", []string{"e4", "e5"}},
+		{"nested variation", "1. e4 e5 (1... c5 2. Nf3 (2. c3) d6) 2. Nf3 *", []string{"e4", "e5", "Nf3"}},
+		{"comment in variation", "1. e4 (1. d4 {a closing bracket ) in a comment}) e5 *", []string{"e4", "e5"}},
+		{"nag", "1. e4 $1 e5 $2 2. Nf3 $14 *", []string{"e4", "e5", "Nf3"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			games, err := Parse(strings.NewReader(test.pgn))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(games[0].Moves, test.moves) {
+				t.Errorf("expected %v, got %v", test.moves, games[0].Moves)
+			}
+		})
+	}
+}
+
+func TestParseUnterminated(t *testing.T) {
+	tests := []string{
+		"1. e4 {never closed",
+		"1. e4 (1. d4 d5 e5 *",
+		`[Event "Casual"`,
+	}
+
+	for _, pgn := range tests {
+		if _, err := Parse(strings.NewReader(pgn)); !errors.Is(err, ErrInvalidPGN) {
+			t.Errorf("%q: expected ErrInvalidPGN, got %v", pgn, err)
+		}
+	}
+}
+
+func TestParseResults(t *testing.T) {
+	tests := []struct {
+		pgn    string
+		result string
+	}{
+		{"1. e4 e5 1-0", ResultWhiteWon},
+		{"1. e4 e5 0-1", ResultBlackWon},
+		{"1. e4 e5 1/2-1/2", ResultDraw},
+		{"1. e4 e5 *", ResultUnknown},
+
+		// the tag wins over the termination marker
+		{"[Result \"0-1\"]\n1. e4 e5 1-0", ResultBlackWon},
+
+		// a game without a termination marker or tag has no known result
+		{"1. e4 e5", ResultUnknown},
+	}
+
+	for _, test := range tests {
+		games, err := Parse(strings.NewReader(test.pgn))
+		if err != nil {
+			t.Fatalf("%q: %v", test.pgn, err)
+		}
+
+		if result := games[0].Result(); result != test.result {
+			t.Errorf("%q: expected %s, got %s", test.pgn, test.result, result)
+		}
+	}
+}
+
+func TestParseGames(t *testing.T) {
+	pgn := `[Event "First"]
+
+1. e4 e5 1-0
+
+[Event "Second"]
+
+1. d4 d5 0-1
+`
+
+	games, err := Parse(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+
+	if games[0].GetTag("Event") != "First" || games[1].GetTag("Event") != "Second" {
+		t.Errorf("the tags went to the wrong games: %v %v", games[0].Tags, games[1].Tags)
+	}
+
+	if !slices.Equal(games[1].Moves, []string{"d4", "d5"}) {
+		t.Errorf("expected the moves of the second game, got %v", games[1].Moves)
+	}
+}
+
+func TestParseNoGame(t *testing.T) {
+	tests := []string{"", "   \n\t", "{only a comment}", "; only a comment\n"}
+
+	for _, pgn := range tests {
+		if _, err := Parse(strings.NewReader(pgn)); !errors.Is(err, ErrNoGame) {
+			t.Errorf("%q: expected ErrNoGame, got %v", pgn, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+	}{
+		{
+			name: "full game",
+			moves: []string{
+				"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "d6",
+				"c3", "O-O", "h3", "Nb8", "d4", "Nbd7", "c4", "c6", "cxb5", "axb5", "Nc3", "Bb7", "Bg5", "b4",
+				"Nb1", "h6", "Bh4", "c5", "dxe5", "Nxe4", "Bxe7", "Qxe7", "exd6", "Qf6", "Nbd2", "Nxd6",
+			},
+		},
+		{
+			name:  "black to move from a position",
+			fen:   "4k3/P7/8/8/8/8/8/4K2r b - - 0 40",
+			moves: []string{"Rh2", "a8=Q+", "Kd7", "Qb7+", "Ke6"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game := &Game{Moves: test.moves}
+			game.SetTag("White", "Alice")
+			game.SetTag("Black", "Bob")
+			game.SetTag("Result", ResultDraw)
+
+			if test.fen != "" {
+				game.SetTag("SetUp", "1")
+				game.SetTag("FEN", test.fen)
+			}
+
+			expected, _, err := game.Replay()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			games, err := Parse(strings.NewReader(game.String()))
+			if err != nil {
+				t.Fatalf("failed to parse the export: %v\n%s", err, game.String())
+			}
+
+			parsed := games[0]
+
+			if !slices.Equal(parsed.Moves, test.moves) {
+				t.Errorf("expected %v, got %v", test.moves, parsed.Moves)
+			}
+
+			for _, tag := range game.Tags {
+				if value := parsed.GetTag(tag.Name); value != tag.Value {
+					t.Errorf("expected %s %q, got %q", tag.Name, tag.Value, value)
+				}
+			}
+
+			board, _, err := parsed.Replay()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if board.FEN() != expected.FEN() {
+				t.Errorf("expected %s, got %s", expected.FEN(), board.FEN())
+			}
+		})
+	}
+}
+
+func TestExportLineLength(t *testing.T) {
+	game := &Game{}
+
+	for i := 0; i < 40; i++ {
+		game.Moves = append(game.Moves, []string{"Nf3", "Nf6", "Ng1", "Ng8"}[i%4])
+	}
+
+	if _, _, err := game.Replay(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(game.String(), "\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("the line is longer than %d characters: %q", maxLineLength, line)
+		}
+	}
+}
+
+func TestReplayIllegalMove(t *testing.T) {
+	games, err := Parse(strings.NewReader("1. e4 e5 2. Ke3 *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := games[0].Replay(); err == nil || !strings.Contains(err.Error(), "move 3") {
+		t.Errorf("expected the third move to be rejected, got %v", err)
+	}
+}
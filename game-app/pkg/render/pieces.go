@@ -0,0 +1,104 @@
+package render
+
+import (
+	"image/color"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+const pieceStrokeWidth = 0.035
+
+var (
+	whitePiece   = color.NRGBA{255, 255, 255, 255}
+	whiteOutline = color.NRGBA{26, 26, 26, 255}
+	blackPiece   = color.NRGBA{51, 51, 51, 255}
+	blackOutline = color.NRGBA{0, 0, 0, 255}
+)
+
+// piecePart is one outline of a piece, a detail like an eye is painted in a color that stands out from the piece
+type piecePart struct {
+	points []point
+	detail bool
+}
+
+// the pieces are drawn in a unit square, the parts are painted in order
+var pieces = map[chessboard.PieceType][]piecePart{
+	chessboard.Pawn: {
+		{points: rect(point{0.27, 0.78}, 0.46, 0.08)},
+		{points: []point{{0.4, 0.46}, {0.6, 0.46}, {0.68, 0.78}, {0.32, 0.78}}},
+		{points: ellipse(0.5, 0.34, 0.13, 0.13)},
+	},
+	chessboard.Rook: {
+		{points: rect(point{0.24, 0.78}, 0.52, 0.08)},
+		{points: rect(point{0.32, 0.38}, 0.36, 0.4)},
+		{points: []point{
+			{0.27, 0.18}, {0.36, 0.18}, {0.36, 0.25}, {0.45, 0.25}, {0.45, 0.18}, {0.55, 0.18},
+			{0.55, 0.25}, {0.64, 0.25}, {0.64, 0.18}, {0.73, 0.18}, {0.73, 0.38}, {0.27, 0.38},
+		}},
+	},
+	chessboard.Knight: {
+		{points: rect(point{0.24, 0.78}, 0.52, 0.08)},
+		{points: []point{
+			{0.33, 0.78}, {0.4, 0.6}, {0.44, 0.5}, {0.34, 0.53}, {0.26, 0.54}, {0.22, 0.46},
+			{0.36, 0.3}, {0.42, 0.2}, {0.48, 0.25}, {0.53, 0.16}, {0.62, 0.28}, {0.68, 0.48},
+			{0.7, 0.78},
+		}},
+		{points: ellipse(0.45, 0.33, 0.025, 0.025), detail: true},
+	},
+	chessboard.Bishop: {
+		{points: rect(point{0.26, 0.78}, 0.48, 0.08)},
+		{points: []point{{0.42, 0.52}, {0.58, 0.52}, {0.65, 0.78}, {0.35, 0.78}}},
+		{points: ellipse(0.5, 0.39, 0.13, 0.17)},
+		{points: ellipse(0.5, 0.18, 0.045, 0.045)},
+		{points: []point{{0.54, 0.3}, {0.58, 0.33}, {0.5, 0.43}, {0.46, 0.4}}, detail: true},
+	},
+	chessboard.Queen: {
+		{points: rect(point{0.24, 0.78}, 0.52, 0.08)},
+		{points: []point{
+			{0.3, 0.78}, {0.2, 0.32}, {0.35, 0.56}, {0.36, 0.26}, {0.44, 0.54}, {0.5, 0.22},
+			{0.56, 0.54}, {0.64, 0.26}, {0.65, 0.56}, {0.8, 0.32}, {0.7, 0.78},
+		}},
+		{points: ellipse(0.2, 0.3, 0.045, 0.045)},
+		{points: ellipse(0.36, 0.24, 0.045, 0.045)},
+		{points: ellipse(0.5, 0.2, 0.045, 0.045)},
+		{points: ellipse(0.64, 0.24, 0.045, 0.045)},
+		{points: ellipse(0.8, 0.3, 0.045, 0.045)},
+	},
+	chessboard.King: {
+		{points: rect(point{0.24, 0.78}, 0.52, 0.08)},
+		{points: []point{{0.3, 0.78}, {0.24, 0.46}, {0.5, 0.38}, {0.76, 0.46}, {0.7, 0.78}}},
+		{points: rect(point{0.46, 0.1}, 0.08, 0.3)},
+		{points: rect(point{0.38, 0.17}, 0.24, 0.08)},
+	},
+}
+
+// pieceShapes returns the shapes of the piece standing on the square with the corner
+func pieceShapes(piece *chessboard.Piece, corner point) []shape {
+	fill, outline := whitePiece, whiteOutline
+	if piece.Color == chessboard.Black {
+		fill, outline = blackPiece, blackOutline
+	}
+
+	parts := pieces[piece.Type]
+	shapes := make([]shape, len(parts))
+
+	for i, part := range parts {
+		points := make([]point, len(part.points))
+		for j, p := range part.points {
+			points[j] = point{corner.x + p.x, corner.y + p.y}
+		}
+
+		shapes[i] = shape{points: points, fill: fill, stroke: outline, strokeWidth: pieceStrokeWidth}
+
+		if part.detail {
+			detail := outline
+			if piece.Color == chessboard.Black {
+				detail = whitePiece
+			}
+
+			shapes[i] = shape{points: points, fill: detail}
+		}
+	}
+
+	return shapes
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+// subsamples is the number of scanlines sampled in every row of pixels, the
+// coverage along a scanline is exact so the edges are antialiased both ways
+const subsamples = 4
+
+// PNG writes the board as a PNG image
+func PNG(w io.Writer, board *chessboard.Chessboard, opts *Options) error {
+	return png.Encode(w, Image(board, opts))
+}
+
+// Image rasterises the board
+func Image(board *chessboard.Chessboard, opts *Options) *image.RGBA {
+	size := opts.size()
+
+	c := &canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, size, size)),
+		scale: float64(size) / 8,
+		cover: make([]float64, size+1),
+	}
+
+	for _, shape := range scene(board, opts) {
+		c.fill(shape.points, shape.fill)
+
+		if shape.strokeWidth > 0 {
+			c.stroke(shape.points, shape.stroke, shape.strokeWidth)
+		}
+	}
+
+	return c.img
+}
+
+type canvas struct {
+	img   *image.RGBA
+	scale float64
+
+	// cover is the coverage of every pixel of the row being filled
+	cover []float64
+}
+
+type crossing struct {
+	x         float64
+	direction int
+}
+
+// fill paints the inside of the outline by the nonzero winding rule
+func (c *canvas) fill(points []point, col color.NRGBA) {
+	if len(points) < 3 {
+		return
+	}
+
+	var (
+		width, height = c.img.Bounds().Dx(), c.img.Bounds().Dy()
+		minY, maxY    = math.Inf(1), math.Inf(-1)
+		pixels        = make([]point, len(points))
+		crossings     []crossing
+	)
+
+	for i, p := range points {
+		pixels[i] = point{p.x * c.scale, p.y * c.scale}
+		minY = math.Min(minY, pixels[i].y)
+		maxY = math.Max(maxY, pixels[i].y)
+	}
+
+	top := max(int(math.Floor(minY)), 0)
+	bottom := min(int(math.Ceil(maxY)), height)
+
+	for y := top; y < bottom; y++ {
+		clear(c.cover)
+
+		for s := 0; s < subsamples; s++ {
+			scanline := float64(y) + (float64(s)+0.5)/subsamples
+			crossings = crossings[:0]
+
+			for i, p := range pixels {
+				q := pixels[(i+1)%len(pixels)]
+
+				if (p.y <= scanline) == (q.y <= scanline) {
+					continue
+				}
+
+				direction := 1
+				if q.y < p.y {
+					direction = -1
+				}
+
+				crossings = append(crossings, crossing{
+					x:         p.x + (scanline-p.y)*(q.x-p.x)/(q.y-p.y),
+					direction: direction,
+				})
+			}
+
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			var (
+				winding = 0
+				start   float64
+			)
+
+			for _, cr := range crossings {
+				previous := winding
+				winding += cr.direction
+
+				if previous == 0 && winding != 0 {
+					start = cr.x
+				} else if previous != 0 && winding == 0 {
+					c.span(start, cr.x, width)
+				}
+			}
+		}
+
+		for x := 0; x < width; x++ {
+			if c.cover[x] > 0 {
+				c.blend(x, y, col, math.Min(c.cover[x], 1))
+			}
+		}
+	}
+}
+
+// span adds the coverage of a part of one scanline to the pixels under it
+func (c *canvas) span(from, to float64, width int) {
+	from = math.Max(from, 0)
+	to = math.Min(to, float64(width))
+
+	for x := int(math.Floor(from)); float64(x) < to; x++ {
+		overlap := math.Min(to, float64(x+1)) - math.Max(from, float64(x))
+		c.cover[x] += overlap / subsamples
+	}
+}
+
+// stroke paints the outline as a band along every edge with round joins
+func (c *canvas) stroke(points []point, col color.NRGBA, width float64) {
+	half := width / 2
+
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+
+		dx, dy := q.x-p.x, q.y-p.y
+
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+
+		nx, ny := -dy/length*half, dx/length*half
+
+		c.fill([]point{{p.x + nx, p.y + ny}, {q.x + nx, q.y + ny}, {q.x - nx, q.y - ny}, {p.x - nx, p.y - ny}}, col)
+		c.fill(ellipse(p.x, p.y, half, half), col)
+	}
+}
+
+// blend paints the color over the pixel, the pixels of the image are always opaque
+func (c *canvas) blend(x, y int, col color.NRGBA, coverage float64) {
+	alpha := float64(col.A) / 255 * coverage
+
+	offset := c.img.PixOffset(x, y)
+	pix := c.img.Pix[offset : offset+4]
+
+	pix[0] = uint8(float64(col.R)*alpha + float64(pix[0])*(1-alpha) + 0.5)
+	pix[1] = uint8(float64(col.G)*alpha + float64(pix[1])*(1-alpha) + 0.5)
+	pix[2] = uint8(float64(col.B)*alpha + float64(pix[2])*(1-alpha) + 0.5)
+	pix[3] = 255
+}
@@ -0,0 +1,195 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+// DefaultSize is the width and height of the image when the options have no size
+const DefaultSize = 480
+
+var (
+	lightSquare = color.NRGBA{240, 217, 181, 255}
+	darkSquare  = color.NRGBA{181, 136, 99, 255}
+	highlight   = color.NRGBA{205, 210, 106, 170}
+	checkColor  = color.NRGBA{230, 30, 30, 170}
+	arrowColor  = color.NRGBA{21, 120, 27, 170}
+)
+
+// Move is a pair of squares, the move to highlight or an arrow to draw
+type Move struct {
+	From chessboard.Position
+	To   chessboard.Position
+}
+
+type Options struct {
+	// Size is the width and height of the image in pixels, DefaultSize when 0
+	Size int
+
+	// Orientation is the side at the bottom of the board, white when empty
+	Orientation chessboard.Color
+
+	// LastMove highlights the squares the last move was played from and to
+	LastMove *Move
+
+	// Arrows are drawn over the pieces
+	Arrows []Move
+}
+
+func (o *Options) size() int {
+	if o == nil || o.Size <= 0 {
+		return DefaultSize
+	}
+
+	return o.Size
+}
+
+// point is a point on the board, a square is one unit wide and y grows downwards
+type point struct {
+	x, y float64
+}
+
+// shape is a closed outline of the scene with its colors, a zero stroke width draws no stroke
+type shape struct {
+	points      []point
+	fill        color.NRGBA
+	stroke      color.NRGBA
+	strokeWidth float64
+}
+
+// scene returns the shapes of the board in the order they are painted
+func scene(board *chessboard.Chessboard, opts *Options) []shape {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	flipped := opts.Orientation == chessboard.Black
+
+	// square returns the top left corner of the square on the image
+	square := func(position chessboard.Position) point {
+		if flipped {
+			return point{float64(7 - position.Col), float64(7 - position.Row)}
+		}
+		return point{float64(position.Col), float64(position.Row)}
+	}
+
+	var shapes []shape
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			fill := lightSquare
+			if (row+col)%2 == 1 {
+				fill = darkSquare
+			}
+
+			shapes = append(shapes, shape{points: rect(square(chessboard.Position{Row: row, Col: col}), 1, 1), fill: fill})
+		}
+	}
+
+	if opts.LastMove != nil {
+		shapes = append(shapes,
+			shape{points: rect(square(opts.LastMove.From), 1, 1), fill: highlight},
+			shape{points: rect(square(opts.LastMove.To), 1, 1), fill: highlight},
+		)
+	}
+
+	if king := checkedKing(board); king != nil {
+		corner := square(*king)
+		shapes = append(shapes, shape{points: ellipse(corner.x+0.5, corner.y+0.5, 0.48, 0.48), fill: checkColor})
+	}
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			if piece := board.Pieces[row][col]; piece != nil {
+				shapes = append(shapes, pieceShapes(piece, square(chessboard.Position{Row: row, Col: col}))...)
+			}
+		}
+	}
+
+	for _, arrow := range opts.Arrows {
+		from, to := square(arrow.From), square(arrow.To)
+
+		if points := arrowOutline(point{from.x + 0.5, from.y + 0.5}, point{to.x + 0.5, to.y + 0.5}); points != nil {
+			shapes = append(shapes, shape{points: points, fill: arrowColor})
+		}
+	}
+
+	return shapes
+}
+
+// checkedKing returns the square of the king of the side to move when it is in check
+func checkedKing(board *chessboard.Chessboard) *chessboard.Position {
+	if !board.IsInCheck(board.Turn) {
+		return nil
+	}
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			if piece := board.Pieces[row][col]; piece != nil && piece.Type == chessboard.King && piece.Color == board.Turn {
+				return &chessboard.Position{Row: row, Col: col}
+			}
+		}
+	}
+
+	return nil
+}
+
+// arrowOutline returns the outline of an arrow from the center of a square to another, nil for an arrow without length
+func arrowOutline(from, to point) []point {
+	const (
+		shaftWidth = 0.16
+		headWidth  = 0.45
+		headLength = 0.4
+	)
+
+	dx, dy := to.x-from.x, to.y-from.y
+
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return nil
+	}
+
+	// the unit vector of the arrow and its normal
+	ux, uy := dx/length, dy/length
+	nx, ny := -uy, ux
+
+	base := point{to.x - ux*headLength, to.y - uy*headLength}
+
+	at := func(p point, offset float64) point {
+		return point{p.x + nx*offset, p.y + ny*offset}
+	}
+
+	return []point{
+		at(from, shaftWidth/2),
+		at(base, shaftWidth/2),
+		at(base, headWidth/2),
+		to,
+		at(base, -headWidth/2),
+		at(base, -shaftWidth/2),
+		at(from, -shaftWidth/2),
+	}
+}
+
+func rect(corner point, width, height float64) []point {
+	return []point{
+		corner,
+		{corner.x + width, corner.y},
+		{corner.x + width, corner.y + height},
+		{corner.x, corner.y + height},
+	}
+}
+
+func ellipse(cx, cy, rx, ry float64) []point {
+	const segments = 32
+
+	points := make([]point, segments)
+
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / segments
+		points[i] = point{cx + rx*math.Cos(angle), cy + ry*math.Sin(angle)}
+	}
+
+	return points
+}
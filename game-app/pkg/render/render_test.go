@@ -0,0 +1,125 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+func TestSVG(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := SVG(&out, chessboard.NewDefault(), &Options{Size: 320}); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := out.String()
+
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="320" height="320" viewBox="0 0 8 8">`) {
+		t.Errorf("unexpected svg header %q", strings.SplitN(svg, "\n", 2)[0])
+	}
+
+	// the squares and every part of the 32 pieces
+	if count := strings.Count(svg, "<polygon"); count <= 64+32 {
+		t.Errorf("expected the squares and the pieces, got %d polygons", count)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	board, err := chessboard.NewFromFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts *Options
+
+		// x and y are the pixel to check on a 80 pixel image, a square is 10 pixels wide
+		x, y     int
+		expected color.NRGBA
+	}{
+		{"light square", nil, 1, 1, lightSquare},
+		{"dark square", nil, 11, 1, darkSquare},
+
+		// h1 is at the bottom right, and at the top left when the board is flipped
+		{"piece", nil, 75, 75, whitePiece},
+		{"flipped", &Options{Orientation: chessboard.Black}, 5, 5, whitePiece},
+		{"last move", &Options{LastMove: &Move{From: chessboard.Position{Row: 7, Col: 7}, To: chessboard.Position{Row: 0, Col: 7}}}, 71, 1, over(darkSquare, highlight)},
+		{"arrow", &Options{Arrows: []Move{{From: chessboard.Position{Row: 4, Col: 0}, To: chessboard.Position{Row: 4, Col: 4}}}}, 41, 44, over(lightSquare, arrowColor)},
+	}
+
+	for _, test := range tests {
+		opts := &Options{Size: 80}
+		if test.opts != nil {
+			opts = test.opts
+			opts.Size = 80
+		}
+
+		var out bytes.Buffer
+
+		if err := PNG(&out, board, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := png.Decode(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if size := img.Bounds().Dx(); size != 80 {
+			t.Fatalf("%s: expected an 80 pixel image, got %d", test.name, size)
+		}
+
+		if got := color.NRGBAModel.Convert(img.At(test.x, test.y)).(color.NRGBA); !closeColor(got, test.expected) {
+			t.Errorf("%s: expected %v at %d,%d, got %v", test.name, test.expected, test.x, test.y, got)
+		}
+	}
+}
+
+func TestCheckHighlight(t *testing.T) {
+	board, err := chessboard.NewFromFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if king := checkedKing(board); king != nil {
+		t.Errorf("no king is in check, got %s", king)
+	}
+
+	board, err = chessboard.NewFromFEN("4k3/8/8/8/8/8/8/4K2r w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if king := checkedKing(board); king == nil || king.String() != "e1" {
+		t.Errorf("expected the king on e1 in check, got %v", king)
+	}
+}
+
+// over paints the color over an opaque background
+func over(background, c color.NRGBA) color.NRGBA {
+	alpha := float64(c.A) / 255
+
+	return color.NRGBA{
+		R: uint8(float64(c.R)*alpha + float64(background.R)*(1-alpha) + 0.5),
+		G: uint8(float64(c.G)*alpha + float64(background.G)*(1-alpha) + 0.5),
+		B: uint8(float64(c.B)*alpha + float64(background.B)*(1-alpha) + 0.5),
+		A: 255,
+	}
+}
+
+func closeColor(a, b color.NRGBA) bool {
+	diff := func(x, y uint8) int {
+		if x > y {
+			return int(x - y)
+		}
+		return int(y - x)
+	}
+
+	return diff(a.R, b.R) <= 2 && diff(a.G, b.G) <= 2 && diff(a.B, b.B) <= 2
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+
+	"github.com/esmailemami/chess/game/pkg/chessboard"
+)
+
+// SVG writes the board as an SVG image, the image is drawn in board units so it scales without loss
+func SVG(w io.Writer, board *chessboard.Chessboard, opts *Options) error {
+	size := opts.size()
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 8 8">`, size, size)
+	out.WriteString("\n")
+
+	for _, shape := range scene(board, opts) {
+		out.WriteString(`<polygon points="`)
+
+		for i, p := range shape.points {
+			if i > 0 {
+				out.WriteByte(' ')
+			}
+			out.WriteString(formatFloat(p.x))
+			out.WriteByte(',')
+			out.WriteString(formatFloat(p.y))
+		}
+
+		fmt.Fprintf(out, `" fill="%s"`, hexColor(shape.fill))
+
+		if shape.fill.A != 255 {
+			fmt.Fprintf(out, ` fill-opacity="%s"`, formatFloat(float64(shape.fill.A)/255))
+		}
+
+		if shape.strokeWidth > 0 {
+			fmt.Fprintf(out, ` stroke="%s" stroke-width="%s" stroke-linejoin="round"`, hexColor(shape.stroke), formatFloat(shape.strokeWidth))
+		}
+
+		out.WriteString("/>\n")
+	}
+
+	out.WriteString("</svg>\n")
+
+	return out.Flush()
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 32)
+}
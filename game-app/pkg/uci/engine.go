@@ -0,0 +1,377 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// stopGrace is how long the engine has to answer a stop with its best move
+	stopGrace = time.Second
+
+	// quitGrace is how long the engine has to exit after a quit
+	quitGrace = time.Second
+
+	skillOption = "Skill Level"
+
+	// maxSkill is the highest Skill Level, the default of the engines that have the option
+	maxSkill = 20
+)
+
+var (
+	ErrEngineExited   = errors.New("the engine process exited")
+	ErrEngineTimeout  = errors.New("the engine did not answer in time")
+	ErrNoBestMove     = errors.New("the engine has no move in the position")
+	ErrEngineNotFound = errors.New("the engine path is not configured")
+)
+
+// Request is a position to search and the limits of the search. A request
+// without any limit searches until its context is done.
+type Request struct {
+	// FEN is the position before the moves, empty for the standard start position
+	FEN string
+
+	// Moves are played on the position in the long algebraic notation, like e7e8q
+	Moves []string
+
+	Depth    int
+	MoveTime time.Duration
+	Nodes    int
+
+	// MultiPV is the number of best lines to find, 0 and 1 find only the best one
+	MultiPV int
+
+	// Skill is the Skill Level of this search, nil searches with the skill of the config
+	Skill *int
+}
+
+// Engine is a running UCI engine process, it searches one request at a time
+type Engine struct {
+	// Name is the name the engine reported with id name
+	Name string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+
+	// options holds the values of the options sent to the engine
+	options map[string]string
+
+	// skill is the Skill Level of the config, nil keeps the engine default
+	skill *int
+
+	// broken is set when the engine stopped answering and has to be replaced
+	broken bool
+}
+
+// Start runs the engine, waits for it to speak UCI and sets the options of the config
+func Start(ctx context.Context, config Config) (*Engine, error) {
+	if config.Path == "" {
+		return nil, ErrEngineNotFound
+	}
+
+	cmd := exec.Command(config.Path, config.Args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 1024),
+		options: make(map[string]string),
+		skill:   config.Skill,
+	}
+
+	go e.read(stdout)
+
+	if err := e.handshake(ctx, config); err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// read passes every line the engine prints to the lines channel until the process exits
+func (e *Engine) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		e.lines <- scanner.Text()
+	}
+
+	close(e.lines)
+}
+
+func (e *Engine) handshake(ctx context.Context, config Config) error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			e.Name = name
+		}
+
+		if line == "uciok" {
+			break
+		}
+	}
+
+	if config.Threads > 0 {
+		e.setOption("Threads", strconv.Itoa(config.Threads))
+	}
+
+	if config.Hash > 0 {
+		e.setOption("Hash", strconv.Itoa(config.Hash))
+	}
+
+	if config.Skill != nil {
+		e.setOption(skillOption, strconv.Itoa(*config.Skill))
+	}
+
+	return e.ready(ctx)
+}
+
+// ready waits until the engine processed every command sent before
+func (e *Engine) ready(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// setOption sends the option unless the engine already has the value
+func (e *Engine) setOption(name, value string) error {
+	if e.options[name] == value {
+		return nil
+	}
+
+	if err := e.send(fmt.Sprintf("setoption name %s value %s", name, value)); err != nil {
+		return err
+	}
+
+	e.options[name] = value
+
+	return nil
+}
+
+// skillLevel returns the Skill Level the request searches with, empty when the
+// engine keeps its own. A search without a skill of its own goes back to the
+// skill of the config, or to the strongest one after a weaker search.
+func (e *Engine) skillLevel(req *Request) string {
+	switch {
+	case req.Skill != nil:
+		return strconv.Itoa(*req.Skill)
+	case e.skill != nil:
+		return strconv.Itoa(*e.skill)
+	case e.options[skillOption] != "":
+		return strconv.Itoa(maxSkill)
+	default:
+		return ""
+	}
+}
+
+// Search searches the position of the request. When the context is done
+// before the engine found its move the search is stopped and the best move so
+// far is returned, unless the engine does not answer the stop either.
+func (e *Engine) Search(ctx context.Context, req *Request) (*Result, error) {
+	result, err := e.search(ctx, req)
+
+	// the engine is somewhere in the middle of the protocol, it can not be trusted with another search
+	if err != nil && err != ErrNoBestMove {
+		e.broken = true
+	}
+
+	return result, err
+}
+
+func (e *Engine) search(ctx context.Context, req *Request) (*Result, error) {
+	multiPV := max(req.MultiPV, 1)
+
+	if err := e.setOption("MultiPV", strconv.Itoa(multiPV)); err != nil {
+		return nil, err
+	}
+
+	if skill := e.skillLevel(req); skill != "" {
+		if err := e.setOption(skillOption, skill); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.ready(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := e.send(positionCommand(req)); err != nil {
+		return nil, err
+	}
+
+	if err := e.send(goCommand(req)); err != nil {
+		return nil, err
+	}
+
+	var (
+		result  = &Result{Lines: make([]Line, 0, multiPV)}
+		stopped = false
+	)
+
+	for {
+		line, err := e.readLine(ctx)
+
+		if errors.Is(err, ErrEngineTimeout) && !stopped {
+			if err := e.send("stop"); err != nil {
+				return nil, err
+			}
+
+			stopped = true
+
+			// the request is done, the engine only has a moment to answer the stop
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), stopGrace)
+			defer cancel()
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if info, ok := strings.CutPrefix(line, "info "); ok {
+			result.addInfo(info)
+			continue
+		}
+
+		if bestMove, ok := strings.CutPrefix(line, "bestmove "); ok {
+			fields := strings.Fields(bestMove)
+
+			if len(fields) == 0 || fields[0] == "(none)" || fields[0] == "0000" {
+				return nil, ErrNoBestMove
+			}
+
+			result.BestMove = fields[0]
+			if len(fields) >= 3 && fields[1] == "ponder" {
+				result.Ponder = fields[2]
+			}
+
+			return result, nil
+		}
+	}
+}
+
+// readLine returns the next line of the engine or ErrEngineTimeout when the context is done first
+func (e *Engine) readLine(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", ErrEngineExited
+		}
+		return line, nil
+
+	case <-ctx.Done():
+		return "", ErrEngineTimeout
+	}
+}
+
+func (e *Engine) send(command string) error {
+	_, err := io.WriteString(e.stdin, command+"\n")
+	return err
+}
+
+// Close asks the engine to quit and kills it if it does not
+func (e *Engine) Close() error {
+	e.send("quit")
+	e.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- e.cmd.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(quitGrace):
+		e.kill()
+		return <-exited
+	}
+}
+
+func (e *Engine) kill() {
+	e.cmd.Process.Kill()
+}
+
+func positionCommand(req *Request) string {
+	var command strings.Builder
+
+	if req.FEN == "" {
+		command.WriteString("position startpos")
+	} else {
+		command.WriteString("position fen ")
+		command.WriteString(req.FEN)
+	}
+
+	if len(req.Moves) > 0 {
+		command.WriteString(" moves ")
+		command.WriteString(strings.Join(req.Moves, " "))
+	}
+
+	return command.String()
+}
+
+func goCommand(req *Request) string {
+	var limits []string
+
+	if req.Depth > 0 {
+		limits = append(limits, "depth", strconv.Itoa(req.Depth))
+	}
+
+	if req.MoveTime > 0 {
+		limits = append(limits, "movetime", strconv.FormatInt(req.MoveTime.Milliseconds(), 10))
+	}
+
+	if req.Nodes > 0 {
+		limits = append(limits, "nodes", strconv.Itoa(req.Nodes))
+	}
+
+	if len(limits) == 0 {
+		return "go infinite"
+	}
+
+	return "go " + strings.Join(limits, " ")
+}
@@ -0,0 +1,133 @@
+package uci
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// the test binary doubles as a tiny fake UCI engine, the tests start it with
+// the fakeEngineEnv variable set and the mode of the engine as its arguments
+const fakeEngineEnv = "UCI_FAKE_ENGINE"
+
+const (
+	// fakeNormal answers every search
+	fakeNormal = "normal"
+
+	// fakeHang never answers a go, not even after a stop
+	fakeHang = "hang"
+
+	// fakeCrash exits when it is asked to search
+	fakeCrash = "crash"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeEngineEnv) == "1" {
+		runFakeEngine(os.Args[1:])
+		os.Exit(0)
+	}
+
+	os.Setenv(fakeEngineEnv, "1")
+	os.Exit(m.Run())
+}
+
+// fakeConfig returns the config of a pool of fake engines, every command the
+// engines receive is appended to the log file when it is set
+func fakeConfig(mode, log string) Config {
+	return Config{
+		Path: os.Args[0],
+		Args: []string{mode, log},
+	}
+}
+
+func runFakeEngine(args []string) {
+	var (
+		mode    = args[0]
+		multiPV = 1
+		scanner = bufio.NewScanner(os.Stdin)
+		log     *os.File
+	)
+
+	if len(args) > 1 && args[1] != "" {
+		log, _ = os.OpenFile(args[1], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		defer log.Close()
+	}
+
+	for scanner.Scan() {
+		command := scanner.Text()
+
+		if log != nil {
+			fmt.Fprintln(log, command)
+		}
+
+		switch {
+		case command == "uci":
+			fmt.Println("id name Fake 1.0")
+			fmt.Println("id author nobody")
+			fmt.Println("option name Hash type spin default 16 min 1 max 1024")
+			fmt.Println("uciok")
+
+		case command == "isready":
+			fmt.Println("readyok")
+
+		case strings.HasPrefix(command, "setoption name MultiPV value "):
+			multiPV, _ = strconv.Atoi(strings.TrimPrefix(command, "setoption name MultiPV value "))
+
+		case command == "go infinite":
+			if mode == fakeHang {
+				continue
+			}
+
+			fakeInfo(1, multiPV)
+
+			for scanner.Scan() {
+				if scanner.Text() == "stop" {
+					break
+				}
+			}
+
+			fmt.Println("bestmove e2e4 ponder e7e5")
+
+		case strings.HasPrefix(command, "go"):
+			if mode == fakeCrash {
+				return
+			}
+
+			if mode == fakeHang {
+				continue
+			}
+
+			depth := 3
+			if fields := strings.Fields(command); len(fields) >= 3 && fields[1] == "depth" {
+				depth, _ = strconv.Atoi(fields[2])
+			}
+
+			for d := 1; d <= depth; d++ {
+				fakeInfo(d, multiPV)
+			}
+
+			fmt.Println("bestmove e2e4 ponder e7e5")
+
+		case command == "quit":
+			return
+		}
+	}
+}
+
+// fakeInfo prints the lines of one iteration, the line of multipv n scores 10*n centipawns less than the best
+func fakeInfo(depth, multiPV int) {
+	moves := []string{"e2e4", "d2d4", "g1f3", "c2c4"}
+
+	fmt.Printf("info depth %d currmove e2e4 currmovenumber 1\n", depth)
+	fmt.Printf("info depth %d score cp 40 lowerbound nodes 10 pv e2e4\n", depth)
+
+	for i := 0; i < multiPV && i < len(moves); i++ {
+		fmt.Printf("info depth %d seldepth %d multipv %d score cp %d nodes %d nps 1000 time 1 pv %s e7e5\n",
+			depth, depth+2, i+1, 30-10*i, depth*100, moves[i])
+	}
+
+	fmt.Println("info string fake engine")
+}
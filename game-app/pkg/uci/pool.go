@@ -0,0 +1,165 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// startTimeout is how long an engine has to finish the UCI handshake
+	startTimeout = 10 * time.Second
+
+	defaultTimeout = 30 * time.Second
+)
+
+var ErrPoolClosed = errors.New("the engine pool is closed")
+
+// Config is the engine binary and the options every process of the pool runs with
+type Config struct {
+	Path string
+	Args []string
+
+	// Processes is the number of engine processes, every process searches one request at a time
+	Processes int
+
+	// Threads and Hash are the values of the UCI options, 0 keeps the engine default
+	Threads int
+	Hash    int
+
+	// Skill is the value of the Skill Level option, nil keeps the engine default
+	Skill *int
+
+	// Timeout bounds every search, it includes the time waiting for a free process
+	Timeout time.Duration
+}
+
+// Pool runs a fixed number of engine processes and hands every search to a
+// free one. A process that stops answering is killed and started again on
+// the next search.
+type Pool struct {
+	config Config
+
+	// engines holds the free processes, a nil entry is a process that has to be started
+	engines chan *Engine
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// NewPool starts the processes of the config, it fails when any of them does not speak UCI
+func NewPool(config Config) (*Pool, error) {
+	if config.Processes <= 0 {
+		config.Processes = 1
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	pool := &Pool{
+		config:  config,
+		engines: make(chan *Engine, config.Processes),
+	}
+
+	for i := 0; i < config.Processes; i++ {
+		engine, err := pool.start()
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+
+		pool.engines <- engine
+	}
+
+	return pool, nil
+}
+
+func (p *Pool) start() (*Engine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	return Start(ctx, p.config)
+}
+
+// Search waits for a free process and searches the request on it, the
+// search is stopped when the timeout of the config or the context is up
+func (p *Pool) Search(ctx context.Context, req *Request) (*Result, error) {
+	p.mutex.Lock()
+	closed := p.closed
+	p.mutex.Unlock()
+
+	if closed {
+		return nil, ErrPoolClosed
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	var engine *Engine
+
+	select {
+	case engine = <-p.engines:
+	case <-ctx.Done():
+		return nil, ErrEngineTimeout
+	}
+
+	if engine == nil {
+		var err error
+
+		if engine, err = p.start(); err != nil {
+			p.release(nil)
+			return nil, err
+		}
+	}
+
+	result, err := engine.Search(ctx, req)
+
+	if engine.broken {
+		go engine.Close()
+		engine = nil
+	}
+
+	p.release(engine)
+
+	return result, err
+}
+
+// release gives the process back to the pool
+func (p *Pool) release(engine *Engine) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		if engine != nil {
+			engine.Close()
+		}
+		return
+	}
+
+	p.engines <- engine
+}
+
+// Close stops the free processes, the busy ones are stopped when their search is done
+func (p *Pool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+
+	for {
+		select {
+		case engine := <-p.engines:
+			if engine != nil {
+				engine.Close()
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package uci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolSearch(t *testing.T) {
+	pool, err := NewPool(fakeConfig(fakeNormal, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	result, err := pool.Search(context.Background(), &Request{Depth: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.BestMove != "e2e4" || result.Ponder != "e7e5" {
+		t.Errorf("got bestmove %s ponder %s", result.BestMove, result.Ponder)
+	}
+
+	if len(result.Lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(result.Lines))
+	}
+
+	line := result.Lines[0]
+	if line.Depth != 5 || line.Score != 30 || line.Nodes != 500 || strings.Join(line.PV, " ") != "e2e4 e7e5" {
+		t.Errorf("got line %+v", line)
+	}
+}
+
+func TestPoolSearchMultiPV(t *testing.T) {
+	pool, err := NewPool(fakeConfig(fakeNormal, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	result, err := pool.Search(context.Background(), &Request{Depth: 2, MultiPV: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"e2e4", "d2d4", "g1f3"}
+	if len(result.Lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(result.Lines), len(want))
+	}
+
+	for i, line := range result.Lines {
+		if line.PV[0] != want[i] || line.Score != 30-10*i {
+			t.Errorf("line %d: got %+v", i+1, line)
+		}
+	}
+}
+
+func TestPoolSendsOptionsAndPosition(t *testing.T) {
+	var (
+		log    = filepath.Join(t.TempDir(), "commands.log")
+		skill  = 5
+		config = fakeConfig(fakeNormal, log)
+	)
+
+	config.Threads = 2
+	config.Hash = 128
+	config.Skill = &skill
+
+	pool, err := NewPool(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pool.Search(context.Background(), &Request{
+		FEN:      "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Moves:    []string{"e2e4", "c7c5"},
+		MoveTime: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.Close()
+
+	commands, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"setoption name Threads value 2",
+		"setoption name Hash value 128",
+		"setoption name Skill Level value 5",
+		"setoption name MultiPV value 1",
+		"position fen rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 moves e2e4 c7c5",
+		"go movetime 50",
+		"quit",
+	} {
+		if !strings.Contains(string(commands), want+"\n") {
+			t.Errorf("the engine did not get %q", want)
+		}
+	}
+}
+
+func TestPoolRequestSkill(t *testing.T) {
+	configSkill := 15
+
+	tests := []struct {
+		name   string
+		config *int
+		want   string
+	}{
+		{"back to the config", &configSkill, "setoption name Skill Level value 15"},
+		{"back to the strongest", nil, "setoption name Skill Level value 20"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				log    = filepath.Join(t.TempDir(), "commands.log")
+				skill  = 3
+				config = fakeConfig(fakeNormal, log)
+			)
+
+			config.Processes = 1
+			config.Skill = test.config
+
+			pool, err := NewPool(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, req := range []*Request{{Depth: 1, Skill: &skill}, {Depth: 1}} {
+				if _, err := pool.Search(context.Background(), req); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pool.Close()
+
+			commands, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			weak := strings.Index(string(commands), "setoption name Skill Level value 3\n")
+			if weak == -1 {
+				t.Fatal("the engine did not get the skill of the request")
+			}
+
+			if !strings.Contains(string(commands)[weak:], test.want+"\n") {
+				t.Errorf("the engine did not get %q after the weak search", test.want)
+			}
+		})
+	}
+}
+
+func TestPoolStopsInfiniteSearch(t *testing.T) {
+	pool, err := NewPool(fakeConfig(fakeNormal, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	result, err := pool.Search(ctx, &Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.BestMove != "e2e4" {
+		t.Errorf("got bestmove %s", result.BestMove)
+	}
+}
+
+func TestPoolTimeout(t *testing.T) {
+	config := fakeConfig(fakeHang, "")
+	config.Timeout = 100 * time.Millisecond
+
+	pool, err := NewPool(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	start := time.Now()
+
+	if _, err := pool.Search(context.Background(), &Request{Depth: 5}); err != ErrEngineTimeout {
+		t.Fatalf("got error %v, want %v", err, ErrEngineTimeout)
+	}
+
+	// the search waits for the stop grace on top of the timeout
+	if elapsed := time.Since(start); elapsed > config.Timeout+stopGrace+time.Second {
+		t.Errorf("search took %s", elapsed)
+	}
+
+	// the hung process is replaced by a new one
+	if engine := <-pool.engines; engine != nil {
+		t.Error("the hung engine is still in the pool")
+	} else {
+		pool.engines <- nil
+	}
+}
+
+func TestPoolRestartsCrashedEngine(t *testing.T) {
+	var (
+		log    = filepath.Join(t.TempDir(), "commands.log")
+		config = fakeConfig(fakeCrash, log)
+	)
+
+	pool, err := NewPool(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Search(context.Background(), &Request{Depth: 1}); err != ErrEngineExited {
+			t.Fatalf("got error %v, want %v", err, ErrEngineExited)
+		}
+	}
+
+	commands, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if started := strings.Count(string(commands), "uci\n"); started != 2 {
+		t.Errorf("the engine was started %d times, want 2", started)
+	}
+}
+
+func TestPoolClosed(t *testing.T) {
+	pool, err := NewPool(fakeConfig(fakeNormal, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.Close()
+
+	if _, err := pool.Search(context.Background(), &Request{Depth: 1}); err != ErrPoolClosed {
+		t.Errorf("got error %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestStartWithoutPath(t *testing.T) {
+	if _, err := NewPool(Config{}); err != ErrEngineNotFound {
+		t.Errorf("got error %v, want %v", err, ErrEngineNotFound)
+	}
+}
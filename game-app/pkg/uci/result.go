@@ -0,0 +1,95 @@
+package uci
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Result is the answer of the engine to a search
+type Result struct {
+	// BestMove is the move the engine plays in the long algebraic notation
+	BestMove string
+
+	// Ponder is the answer the engine expects, empty when it has none
+	Ponder string
+
+	// Lines are the best lines of the last iteration, the best one first
+	Lines []Line
+}
+
+// Line is one principal variation reported by the engine
+type Line struct {
+	Depth int
+	Nodes int
+
+	// Score is the evaluation in centipawns from the point of view of the side to move
+	Score int
+
+	// Mate is the number of moves to mate, negative when the side to move is
+	// mated and 0 when the engine sees no mate
+	Mate int
+
+	PV []string
+}
+
+// addInfo keeps the lines of the info the engine printed during the search,
+// only the exact scores with a principal variation are kept
+func (r *Result) addInfo(info string) {
+	var (
+		fields  = strings.Fields(info)
+		line    Line
+		multiPV = 1
+		bound   = false
+	)
+
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "string":
+			return
+
+		case "depth":
+			line.Depth = intField(fields, &i)
+
+		case "nodes":
+			line.Nodes = intField(fields, &i)
+
+		case "multipv":
+			multiPV = intField(fields, &i)
+
+		case "cp":
+			line.Score = intField(fields, &i)
+
+		case "mate":
+			line.Mate = intField(fields, &i)
+
+		case "lowerbound", "upperbound":
+			bound = true
+
+		case "pv":
+			line.PV = fields[i+1:]
+			i = len(fields)
+		}
+	}
+
+	if bound || len(line.PV) == 0 || multiPV < 1 {
+		return
+	}
+
+	for len(r.Lines) < multiPV {
+		r.Lines = append(r.Lines, Line{})
+	}
+
+	r.Lines[multiPV-1] = line
+}
+
+// intField parses the value after the field at i and moves i past it
+func intField(fields []string, i *int) int {
+	if *i+1 >= len(fields) {
+		return 0
+	}
+
+	*i++
+	value, _ := strconv.Atoi(fields[*i])
+
+	return value
+}
@@ -9,13 +9,42 @@ const (
 	// game
 	ChessValidMoves = "chess-valid-moves"
 	ChessMovePiece  = "chess-move-piece"
+	ChessClaimDraw  = "chess-claim-draw"
+
+	// takeback, the request is sent to the opponent as well
+	ChessRequestTakeback = "chess-takeback-request"
+	ChessTakebackAccept  = "chess-takeback-accept"
+	ChessTakebackDecline = "chess-takeback-decline"
+
+	// resign and draw offers, the offer and its answer are sent to the players and watchers
+	ChessResign      = "chess-resign"
+	ChessDrawOffer   = "chess-draw-offer"
+	ChessDrawAccept  = "chess-draw-accept"
+	ChessDrawDecline = "chess-draw-decline"
+	ChessDrawExpired = "chess-draw-expired"
+
+	// abort is allowed before the first move of the player
+	ChessAbort = "chess-abort"
+
+	// rematch of a finished game, the offer and its answer are sent to the players and watchers
+	ChessRematchOffer   = "chess-rematch-offer"
+	ChessRematchAccept  = "chess-rematch-accept"
+	ChessRematchDecline = "chess-rematch-decline"
+	ChessRematchExpired = "chess-rematch-expired"
 
 	// send types
 	NewBoard          = "new-board"
 	ChessInCheck      = "chess-in-check"
-	ChessCheckmate    = "chess-chackmate"
+	ChessGameOver     = "chess-game-over"
 	ChessPlayerJoined = "chess-player-joined"
 	ChessNewWatcher   = "chess-new-watcher"
+	ChessTakeback     = "chess-takeback"
+	ChessReviewReady  = "chess-review-ready"
+
+	// challenges are answered over the api, the answer is sent to the challenger
+	ChessChallenge        = "chess-challenge"
+	ChessChallengeDecline = "chess-challenge-decline"
+	ChessRematch          = "chess-rematch"
 )
 
 var (
@@ -23,6 +52,21 @@ var (
 	ChessUnregisterCh = make(chan *websocket.Client, 256)
 	ChessValidMovesCh = make(chan *websocket.ClientMessage[ChessValidMovesRequest], 256)
 	ChessMovePieceCh  = make(chan *websocket.ClientMessage[ChessMovePieceRequest], 256)
+	ChessClaimDrawCh  = make(chan *websocket.ClientMessage[ChessClaimDrawRequest], 256)
+
+	ChessRequestTakebackCh = make(chan *websocket.ClientMessage[ChessTakebackRequest], 256)
+	ChessTakebackAcceptCh  = make(chan *websocket.ClientMessage[ChessTakebackRequest], 256)
+	ChessTakebackDeclineCh = make(chan *websocket.ClientMessage[ChessTakebackRequest], 256)
+
+	ChessResignCh      = make(chan *websocket.ClientMessage[ChessResignRequest], 256)
+	ChessDrawOfferCh   = make(chan *websocket.ClientMessage[ChessDrawRequest], 256)
+	ChessDrawAcceptCh  = make(chan *websocket.ClientMessage[ChessDrawRequest], 256)
+	ChessDrawDeclineCh = make(chan *websocket.ClientMessage[ChessDrawRequest], 256)
+	ChessAbortCh       = make(chan *websocket.ClientMessage[ChessAbortRequest], 256)
+
+	ChessRematchOfferCh   = make(chan *websocket.ClientMessage[ChessRematchRequest], 256)
+	ChessRematchAcceptCh  = make(chan *websocket.ClientMessage[ChessRematchRequest], 256)
+	ChessRematchDeclineCh = make(chan *websocket.ClientMessage[ChessRematchRequest], 256)
 )
 
 func ChessOnMessage(c *websocket.Client, msg *websocket.Message) {
@@ -41,6 +85,90 @@ func ChessOnMessage(c *websocket.Client, msg *websocket.Message) {
 		}
 
 		ChessMovePieceCh <- websocket.NewClientMessage(c, req)
+	case ChessClaimDraw:
+		var req ChessClaimDrawRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessClaimDrawCh <- websocket.NewClientMessage(c, req)
+	case ChessRequestTakeback:
+		var req ChessTakebackRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessRequestTakebackCh <- websocket.NewClientMessage(c, req)
+	case ChessTakebackAccept:
+		var req ChessTakebackRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessTakebackAcceptCh <- websocket.NewClientMessage(c, req)
+	case ChessTakebackDecline:
+		var req ChessTakebackRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessTakebackDeclineCh <- websocket.NewClientMessage(c, req)
+	case ChessResign:
+		var req ChessResignRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessResignCh <- websocket.NewClientMessage(c, req)
+	case ChessDrawOffer:
+		var req ChessDrawRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessDrawOfferCh <- websocket.NewClientMessage(c, req)
+	case ChessDrawAccept:
+		var req ChessDrawRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessDrawAcceptCh <- websocket.NewClientMessage(c, req)
+	case ChessDrawDecline:
+		var req ChessDrawRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessDrawDeclineCh <- websocket.NewClientMessage(c, req)
+	case ChessAbort:
+		var req ChessAbortRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessAbortCh <- websocket.NewClientMessage(c, req)
+	case ChessRematchOffer:
+		var req ChessRematchRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessRematchOfferCh <- websocket.NewClientMessage(c, req)
+	case ChessRematchAccept:
+		var req ChessRematchRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessRematchAcceptCh <- websocket.NewClientMessage(c, req)
+	case ChessRematchDecline:
+		var req ChessRematchRequest
+		if !c.Unmarshal(msg.Content, &req) {
+			return
+		}
+
+		ChessRematchDeclineCh <- websocket.NewClientMessage(c, req)
 	default:
 		logging.Warn("websocket invalid message type", "type", msg.Type)
 	}
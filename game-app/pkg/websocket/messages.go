@@ -8,7 +8,32 @@ type ChessValidMovesRequest struct {
 }
 
 type ChessMovePieceRequest struct {
+	GameID    uuid.UUID `json:"gameId"`
+	From      string    `json:"position"`
+	To        string    `json:"to"`
+	Promotion string    `json:"promotion,omitempty"` // Q, R, B or N
+}
+
+type ChessClaimDrawRequest struct {
+	GameID uuid.UUID `json:"gameId"`
+}
+
+type ChessTakebackRequest struct {
+	GameID uuid.UUID `json:"gameId"`
+}
+
+type ChessResignRequest struct {
+	GameID uuid.UUID `json:"gameId"`
+}
+
+type ChessDrawRequest struct {
+	GameID uuid.UUID `json:"gameId"`
+}
+
+type ChessAbortRequest struct {
+	GameID uuid.UUID `json:"gameId"`
+}
+
+type ChessRematchRequest struct {
 	GameID uuid.UUID `json:"gameId"`
-	From   string    `json:"position"`
-	To     string    `json:"to"`
 }
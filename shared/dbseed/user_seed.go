@@ -52,6 +52,19 @@ func seedUser(dbConn *gorm.DB) error {
 			Enabled: true,
 			RoleID:  models.ROLE_ADMIN,
 		},
+		{
+			Model: models.Model{
+				ID: models.USER_COMPUTER,
+			},
+			FirstName: func() *string {
+				value := "Computer"
+				return &value
+			}(),
+			Username: "computer",
+			Enabled:  false,
+			RoleID:   models.ROLE_USER,
+			IsSystem: true,
+		},
 	}
 
 	for _, item := range items {
@@ -77,6 +90,7 @@ func seedUser(dbConn *gorm.DB) error {
 				"mobile":     item.Mobile,
 				"enabled":    item.Enabled,
 				"role_id":    item.RoleID.String(),
+				"is_system":  item.IsSystem,
 				"updated_at": time.Now(),
 			}).Error
 			if err != nil {
@@ -2,6 +2,9 @@ package models
 
 import "github.com/google/uuid"
 
+// USER_COMPUTER is the system user the built-in computer opponent of the game service plays as
+var USER_COMPUTER = uuid.MustParse("0b5a3c1e-7d2f-4c8a-9e61-3f4d2b7c8a90")
+
 type User struct {
 	Model
 
@@ -14,6 +17,7 @@ type User struct {
 	Role      *Role     `gorm:"foreignKey:role_id;references:id" json:"role"`
 	Enabled   bool      `gorm:"enabled" json:"enabled"`
 	Profile   string    `gorm:"column:profile" json:"profile"`
+	IsSystem  bool      `gorm:"column:is_system" json:"isSystem"`
 }
 
 func (User) TableName() string {